@@ -1,66 +1,283 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"store-management/internal/config"
+	"store-management/internal/cookie"
 	"store-management/internal/datasource"
+	"store-management/internal/health"
+	"store-management/internal/logging"
+	"store-management/internal/metrics"
 	"store-management/internal/middleware"
+	"store-management/internal/migrate"
 	"store-management/internal/repository"
 	"store-management/internal/response"
 	"store-management/internal/router"
 	"store-management/internal/service"
+	"store-management/internal/sms"
+	"store-management/internal/token"
+	"store-management/tools/migrations"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	err := godotenv.Load(".env")
+	// exitCode is set instead of exiting where the deferred closes below must
+	// still run. This deferred call is the first, so it runs after them.
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [migrate up|down|status|redo]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	logLevel := new(slog.LevelVar)
+	slog.SetDefault(logging.New(os.Stderr, logLevel))
+
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fatal("Error loading configuration", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Error validating configuration", err)
+	}
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logLevel.Set(level)
 
 	shutdownChan := make(chan os.Signal, 1)
 	signal.Notify(shutdownChan, os.Interrupt, syscall.SIGTERM)
-	signal.Notify(shutdownChan, os.Interrupt, syscall.SIGINT)
 
+	if flag.Arg(0) == "migrate" {
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		sqlWriter := connectMySQL("writer", cfg.Database, cfg.Database.Writer)
+		defer sqlWriter.Close()
+		if err := runMigration(context.Background(), sqlWriter, flag.Arg(1)); err != nil {
+			fatal("Error migrating the database", err)
+		}
+		return
+	}
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	keySet, err := cfg.JWT.KeySet()
+	if err != nil {
+		fatal("Error loading the JWT keys", err)
+	}
+	token.SetKeySet(keySet)
+	cookieOptions, err := cfg.Cookie.Options()
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		fatal("Error configuring cookies", err)
+	}
+	if err := cookie.SetOptions(cookieOptions); err != nil {
+		fatal("Error configuring cookies", err)
 	}
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(middleware.RequestIDMiddleware(), middleware.LoggerMiddleware(), metrics.Middleware(), middleware.ErrorMiddleware())
+	// Login throttling is keyed by the client IP, so X-Forwarded-For is only
+	// honored when it comes from a configured trusted proxy.
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("Error parsing the trusted proxies", err)
+	}
+	readiness := health.NewReadiness()
 	r.GET("/healthcheck", func(c *gin.Context) {
-		c.JSON(200, response.New(http.StatusOK, response.MessageOK, nil))
+		if readiness.Draining() {
+			response.JSON(c, http.StatusServiceUnavailable, response.New(http.StatusServiceUnavailable, response.MessageUnavailable, nil))
+			return
+		}
+		response.JSON(c, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
 	})
+	r.GET("/livez", health.LivenessHandler())
+	r.GET("/readyz", readiness.Handler())
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-	sqlWriter := datasource.NewMySQL(&datasource.MySQLConfig{
-		User:   os.Getenv("DB_WRITER_USER"),
-		Passwd: os.Getenv("DB_WRITER_PASS"),
-		Host:   os.Getenv("DB_WRITER_HOST"),
-		Port:   os.Getenv("DB_WRITER_PORT"),
-		DBName: os.Getenv("DB_WRITER_DBNAME"),
-	})
-	defer sqlWriter.Close()
-	sqlReader := datasource.NewMySQL(&datasource.MySQLConfig{
-		User:   os.Getenv("DB_READER_USER"),
-		Passwd: os.Getenv("DB_READER_PASS"),
-		Host:   os.Getenv("DB_READER_HOST"),
-		Port:   os.Getenv("DB_READER_PORT"),
-		DBName: os.Getenv("DB_READER_DBNAME"),
-	})
-	defer sqlReader.Close()
+	// Deferred closes run last, once the server has drained or failed below.
 	cache := datasource.NewInMemoryCache()
-	repository.Init(sqlWriter, sqlReader, sqlWriter, cache)
-	service.Init(repository.Get())
-	r.Use(middleware.JwtMiddleware(repository.Get().UserRepository()))
+	defer cache.Close()
+	readiness.Add("cache", cfg.Health.CacheTimeout, health.CacheCheck(cache))
+	metrics.RegisterCache(cache)
+	switch cfg.Repository {
+	case "mysql":
+		sqlWriter := connectMySQL("writer", cfg.Database, cfg.Database.Writer)
+		defer sqlWriter.Close()
+		sqlReader := connectMySQL("reader", cfg.Database, cfg.Database.Reader)
+		defer sqlReader.Close()
+		if cfg.MigrateOnStart {
+			if err := runMigration(context.Background(), sqlWriter, "up"); err != nil {
+				fatal("Error migrating the database", err)
+			}
+		}
+		metrics.RegisterDB("writer", sqlWriter.DB.DB)
+		metrics.RegisterDB("reader", sqlReader.DB.DB)
+		readiness.Add("writer", cfg.Health.DatabaseTimeout, health.PingCheck(sqlWriter))
+		readiness.Add("reader", cfg.Health.DatabaseTimeout, health.PingCheck(sqlReader))
+		migrator, err := newMigrator(sqlWriter)
+		if err != nil {
+			fatal("Error loading migrations", err)
+		}
+		readiness.Add("migrations", cfg.Health.MigrationTimeout, health.MigrationCheck(migrator))
+		repository.Init(sqlWriter, sqlReader, sqlWriter, cache)
+	case "memory":
+		slog.Warn("Keeping data in memory, it is lost when the server stops")
+		repository.InitInMemory(cache)
+	}
+	service.Init(repository.Get(), newSMSSender(cfg.SMS))
+	r.Use(middleware.APIKeyMiddleware(service.Get().APIKeyService))
+	r.Use(middleware.JwtMiddleware(repository.Get().UserRepository(), service.Get().SessionService))
+	r.Use(middleware.CSRFMiddleware(router.CSRFExemptPaths...))
 	router.Init(r, service.Get())
 
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(1)
 	go func() {
-		err = r.Run()
+		defer workers.Done()
+		cache.RunJanitor(workerCtx, time.Minute)
+	}()
+
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", cfg.Server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case sig := <-shutdownChan:
+		slog.Info("Shutting down", "signal", sig.String())
+		shutdown(server, readiness, cfg.Server.DrainDelay, cfg.Server.ShutdownTimeout)
+	case err := <-serveErr:
+		// Nothing is served, so there is nothing to drain, but the workers and
+		// connections are still closed before exiting.
+		slog.Error("Error serving", "error", err)
+		exitCode = 1
+	}
+	stopWorkers()
+	workers.Wait()
+}
+
+// shutdown fails readiness checks and keeps serving for drainDelay, so that
+// load balancers stop sending requests first. It then stops accepting
+// connections and waits for in-flight requests until the timeout, after
+// which the remaining connections are cut.
+func shutdown(server *http.Server, readiness *health.Readiness, drainDelay, timeout time.Duration) {
+	readiness.Drain()
+	time.Sleep(drainDelay)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Error draining connections", "error", err)
+		_ = server.Close()
+	}
+}
+
+func connectMySQL(role string, database config.DatabaseConfig, pool config.PoolConfig) *datasource.MySQL {
+	mysqlConfig, err := database.MySQLConfig(pool)
+	if err != nil {
+		fatal("Error configuring the database", err, "pool", role)
+	}
+	db, err := datasource.NewMySQL(mysqlConfig)
+	if err != nil {
+		fatal("Error connecting to the database", err, "pool", role)
+	}
+	return db
+}
+
+// newMigrator returns a migrator for the migrations built into the server.
+func newMigrator(db *datasource.MySQL) (*migrate.Migrator, error) {
+	migrator, err := migrate.New(db.DB.DB, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	migrator.Logf = func(format string, args ...interface{}) {
+		slog.Info(fmt.Sprintf(format, args...))
+	}
+	return migrator, nil
+}
+
+// runMigration runs the embedded migrations for one of the migrate
+// subcommands: up, down, status or redo.
+func runMigration(ctx context.Context, db *datasource.MySQL, command string) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "redo":
+		return migrator.Redo(ctx)
+	case "status":
+		statuses, err := migrator.Status(ctx)
 		if err != nil {
-			panic(err)
+			return err
 		}
-	}()
+		fmt.Printf("%-25s %s\n", "Applied At", "Migration")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-25s %s\n", appliedAt, status.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down, status or redo", command)
+	}
+}
 
-	<-shutdownChan
+// newSMSSender picks the configured SMS delivery. Only local senders exist
+// for now: "console" prints messages to stdout and "file" appends them to a
+// file.
+func newSMSSender(smsConfig config.SMSConfig) sms.SMSSender {
+	switch smsConfig.Sender {
+	case "file":
+		sender, err := sms.NewFileSender(smsConfig.FilePath)
+		if err != nil {
+			fatal("Error opening SMS file", err)
+		}
+		return sender
+	default:
+		return sms.NewConsoleSender()
+	}
+}
+
+// fatal logs err and exits. A configuration error lists every problem.
+func fatal(msg string, err error, args ...any) {
+	var configErr *config.Error
+	if errors.As(err, &configErr) {
+		args = append(args, "problems", configErr.Problems)
+	} else {
+		args = append(args, "error", err)
+	}
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"store-management/internal/health"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown_DrainsBeforeClosingConnections(t *testing.T) {
+	readiness := health.NewReadiness()
+	server := &http.Server{}
+	type shutdownState struct {
+		draining bool
+		after    time.Duration
+	}
+	started := time.Now()
+	states := make(chan shutdownState, 1)
+	server.RegisterOnShutdown(func() {
+		states <- shutdownState{draining: readiness.Draining(), after: time.Since(started)}
+	})
+
+	drainDelay := 50 * time.Millisecond
+	shutdown(server, readiness, drainDelay, time.Second)
+
+	select {
+	case state := <-states:
+		assert.True(t, state.draining)
+		assert.GreaterOrEqual(t, state.after, drainDelay)
+	case <-time.After(time.Second):
+		require.Fail(t, "server was not shut down")
+	}
+}
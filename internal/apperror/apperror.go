@@ -0,0 +1,120 @@
+// Package apperror defines the errors shared by the layers of the server.
+// Each error has a Kind that decides how it is reported to clients, so that
+// repositories and services can fail without knowing about HTTP.
+package apperror
+
+import (
+	"errors"
+	"strings"
+)
+
+type Kind uint8
+
+const (
+	// KindInternal is a failure the client cannot do anything about. It is
+	// the kind of every error that does not have one.
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooManyRequests
+	// KindUnavailable is a failure of a dependency, such as the database,
+	// that may go away when retried.
+	KindUnavailable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindTooManyRequests:
+		return "too many requests"
+	case KindUnavailable:
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
+
+// Error is an error of a given kind. Message is shown to clients unless the
+// kind is internal or unavailable; Op names the operation that failed and,
+// like Err, is only logged.
+type Error struct {
+	Kind    Kind
+	Op      string
+	Message string
+	Err     error
+}
+
+// New returns an error meant to be declared once and compared with
+// errors.Is, like the sentinel errors of the services.
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap annotates err with the operation that failed and a kind. It returns
+// nil when err is nil.
+func Wrap(kind Kind, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Op: op, Err: err}
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Op != "" {
+		b.WriteString(e.Op)
+		b.WriteString(": ")
+	}
+	if e.Message != "" {
+		b.WriteString(e.Message)
+		if e.Err != nil {
+			b.WriteString(": ")
+		}
+	}
+	if e.Err != nil {
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the first kind other than KindInternal in the chain of
+// err, so that wrapping an error to add context never hides its kind.
+func KindOf(err error) Kind {
+	for e := (*Error)(nil); errors.As(err, &e); err = e.Err {
+		if e.Kind != KindInternal {
+			return e.Kind
+		}
+	}
+	return KindInternal
+}
+
+// Message returns the message of err that is safe to show to clients, or an
+// empty string when err has none.
+func Message(err error) string {
+	switch KindOf(err) {
+	case KindInternal, KindUnavailable:
+		return ""
+	}
+	for e := (*Error)(nil); errors.As(err, &e); err = e.Err {
+		if e.Message != "" {
+			return e.Message
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	notFound := New(KindNotFound, "store not found")
+
+	assert.Equal(t, KindNotFound, KindOf(notFound))
+	assert.Equal(t, KindNotFound, KindOf(fmt.Errorf("find store: %w", notFound)))
+	assert.Equal(t, KindNotFound, KindOf(Wrap(KindInternal, "store.Find", notFound)))
+	assert.Equal(t, KindUnavailable, KindOf(Wrap(KindUnavailable, "mysql", sql.ErrConnDone)))
+	assert.Equal(t, KindInternal, KindOf(errors.New("boom")))
+	assert.Equal(t, KindInternal, KindOf(nil))
+}
+
+func TestMessage(t *testing.T) {
+	notFound := New(KindNotFound, "store not found")
+
+	assert.Equal(t, "store not found", Message(Wrap(KindInternal, "store.Find", notFound)))
+	assert.Equal(t, "", Message(Wrap(KindUnavailable, "mysql", sql.ErrConnDone)))
+	assert.Equal(t, "", Message(errors.New("boom")))
+}
+
+func TestError(t *testing.T) {
+	assert.Equal(t, "store not found", New(KindNotFound, "store not found").Error())
+	assert.Equal(t, "mysql: sql: connection is already closed", Wrap(KindUnavailable, "mysql", sql.ErrConnDone).Error())
+	assert.Nil(t, Wrap(KindInternal, "mysql", nil))
+
+	err := Wrap(KindConflict, "mysql", sql.ErrNoRows)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
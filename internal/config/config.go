@@ -0,0 +1,382 @@
+// Package config loads the server configuration. Values come from, in order
+// of precedence: command line flags, environment variables (including the
+// ones in .env), an optional YAML file and the defaults.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"store-management/internal/cookie"
+	"store-management/internal/datasource"
+	"store-management/internal/logging"
+	"store-management/internal/token"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	Cookie   CookieConfig   `yaml:"cookie"`
+	SMS      SMSConfig      `yaml:"sms"`
+	Health   HealthConfig   `yaml:"health"`
+	Log      LogConfig      `yaml:"log"`
+	// Repository is where data is kept: mysql, or memory for local
+	// development without a database.
+	Repository string `yaml:"repository"`
+	// MigrateOnStart applies pending migrations before serving.
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+	// ReadTimeout, WriteTimeout and IdleTimeout bound reading a request,
+	// writing its response and keeping an idle connection open.
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// DrainDelay is how long the server keeps serving after it starts
+	// failing readiness checks on shutdown, so that load balancers stop
+	// routing to it before it stops accepting connections.
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// ShutdownTimeout is how long in-flight requests may take to finish
+	// once the server stops accepting connections.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// TrustedProxies are the proxy IPs or CIDRs allowed to set
+	// X-Forwarded-For. Login throttling is keyed by the client IP, so no
+	// proxy is trusted by default.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	// Timezone is the IANA time zone of the MySQL sessions.
+	Timezone string `yaml:"timezone"`
+	// QueryTimeout is the longest a single statement may run.
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+	Writer       PoolConfig    `yaml:"writer"`
+	Reader       PoolConfig    `yaml:"reader"`
+}
+
+type PoolConfig struct {
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	Name            string        `yaml:"name"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+type JWTConfig struct {
+	// KeysFile is a JSON key set for signing access tokens. Secret is used
+	// as a single HS256 key when it is empty.
+	KeysFile string `yaml:"keys_file"`
+	Secret   string `yaml:"secret"`
+}
+
+type CookieConfig struct {
+	Secure bool   `yaml:"secure"`
+	Domain string `yaml:"domain"`
+	// SameSite is lax, strict or none, which requires Secure.
+	SameSite string `yaml:"same_site"`
+}
+
+type SMSConfig struct {
+	// Sender is console, printing messages to stdout, or file, appending
+	// them to FilePath.
+	Sender   string `yaml:"sender"`
+	FilePath string `yaml:"file_path"`
+}
+
+// HealthConfig bounds each readiness check, so one slow dependency is
+// reported as such instead of failing the whole probe.
+type HealthConfig struct {
+	DatabaseTimeout  time.Duration `yaml:"database_timeout"`
+	CacheTimeout     time.Duration `yaml:"cache_timeout"`
+	MigrationTimeout time.Duration `yaml:"migration_timeout"`
+}
+
+type LogConfig struct {
+	// Level is the lowest level logged: debug, info, warn or error.
+	Level string `yaml:"level"`
+}
+
+// Default returns the configuration used for everything left unset.
+func Default() *Config {
+	pool := PoolConfig{
+		Port:            "3306",
+		MaxOpenConns:    30,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 3 * time.Minute,
+		ConnMaxIdleTime: time.Minute,
+	}
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     time.Minute,
+			DrainDelay:      5 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Timezone:     "Asia/Seoul",
+			QueryTimeout: 5 * time.Second,
+			Writer:       pool,
+			Reader:       pool,
+		},
+		Cookie: CookieConfig{SameSite: "lax"},
+		SMS:    SMSConfig{Sender: "console"},
+		Log:    LogConfig{Level: "info"},
+		Health: HealthConfig{
+			DatabaseTimeout:  2 * time.Second,
+			CacheTimeout:     500 * time.Millisecond,
+			MigrationTimeout: 3 * time.Second,
+		},
+		Repository: "mysql",
+	}
+}
+
+// Load registers the configuration flags on fs, parses args with it and
+// loads the configuration. The YAML file is named by the -config flag or
+// CONFIG_FILE. A missing .env is not an error.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	configFile := fs.String("config", "", "YAML configuration file, overridden by environment variables")
+	addr := fs.String("addr", "", "address to listen on, e.g. :8080")
+	repository := fs.String("repository", "", "where to keep data: mysql, or memory for local development without a database")
+	migrateOnStart := fs.Bool("migrate-on-start", false, "apply pending database migrations before serving")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading .env: %w", err)
+	}
+
+	config := Default()
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		if err := config.readFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.readEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			config.Server.Addr = *addr
+		case "repository":
+			config.Repository = *repository
+		case "migrate-on-start":
+			config.MigrateOnStart = *migrateOnStart
+		}
+	})
+	return config, nil
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readEnv overrides the configuration with the environment variables that
+// are set, reporting every malformed one at once.
+func (c *Config) readEnv(lookup func(string) (string, bool)) error {
+	env := &envReader{lookup: lookup}
+
+	env.string("SERVER_ADDR", &c.Server.Addr)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SERVER_DRAIN_DELAY", &c.Server.DrainDelay)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.list("TRUSTED_PROXIES", &c.Server.TrustedProxies)
+
+	env.string("DB_TIMEZONE", &c.Database.Timezone)
+	env.duration("DB_QUERY_TIMEOUT", &c.Database.QueryTimeout)
+	env.pool("DB_WRITER_", &c.Database.Writer)
+	env.pool("DB_READER_", &c.Database.Reader)
+
+	env.string("JWT_KEYS_FILE", &c.JWT.KeysFile)
+	env.string("JWT_SECRET", &c.JWT.Secret)
+
+	env.bool("COOKIE_SECURE", &c.Cookie.Secure)
+	env.string("COOKIE_DOMAIN", &c.Cookie.Domain)
+	env.string("COOKIE_SAMESITE", &c.Cookie.SameSite)
+
+	env.string("SMS_SENDER", &c.SMS.Sender)
+	env.string("SMS_FILE_PATH", &c.SMS.FilePath)
+
+	env.duration("HEALTH_DATABASE_TIMEOUT", &c.Health.DatabaseTimeout)
+	env.duration("HEALTH_CACHE_TIMEOUT", &c.Health.CacheTimeout)
+	env.duration("HEALTH_MIGRATION_TIMEOUT", &c.Health.MigrationTimeout)
+
+	env.string("LOG_LEVEL", &c.Log.Level)
+
+	env.string("REPOSITORY", &c.Repository)
+	env.bool("MIGRATE_ON_START", &c.MigrateOnStart)
+
+	if len(env.problems) > 0 {
+		return &Error{Problems: env.problems}
+	}
+	return nil
+}
+
+// Error lists everything wrong with a configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration for missing or inconsistent values,
+// reporting all of them at once.
+func (c *Config) Validate() error {
+	var problems []string
+	required := func(name, value string) {
+		if value == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+
+	if c.Server.Addr == "" {
+		problems = append(problems, "server address is required")
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server timeouts must be positive")
+	}
+	if c.Server.DrainDelay < 0 {
+		problems = append(problems, "SERVER_DRAIN_DELAY must not be negative")
+	}
+	if c.Health.DatabaseTimeout <= 0 || c.Health.CacheTimeout <= 0 || c.Health.MigrationTimeout <= 0 {
+		problems = append(problems, "health check timeouts must be positive")
+	}
+
+	switch c.Repository {
+	case "memory":
+	case "mysql":
+		if _, err := time.LoadLocation(c.Database.Timezone); err != nil || c.Database.Timezone == "" {
+			problems = append(problems, fmt.Sprintf("database timezone %q is not a known time zone", c.Database.Timezone))
+		}
+		if c.Database.QueryTimeout <= 0 {
+			problems = append(problems, "database query timeout must be positive")
+		}
+		for _, pool := range []struct {
+			name   string
+			prefix string
+			config PoolConfig
+		}{
+			{"writer", "DB_WRITER_", c.Database.Writer},
+			{"reader", "DB_READER_", c.Database.Reader},
+		} {
+			required(pool.prefix+"HOST", pool.config.Host)
+			required(pool.prefix+"PORT", pool.config.Port)
+			required(pool.prefix+"DBNAME", pool.config.Name)
+			required(pool.prefix+"USER", pool.config.User)
+			if pool.config.MaxOpenConns < 0 || pool.config.MaxIdleConns < 0 {
+				problems = append(problems, pool.name+" pool sizes must not be negative")
+			}
+			if pool.config.MaxOpenConns > 0 && pool.config.MaxIdleConns > pool.config.MaxOpenConns {
+				problems = append(problems, pool.name+" pool keeps more idle connections than it may open")
+			}
+			if pool.config.ConnMaxLifetime < 0 || pool.config.ConnMaxIdleTime < 0 {
+				problems = append(problems, pool.name+" connection lifetimes must not be negative")
+			}
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown repository %q, expected mysql or memory", c.Repository))
+	}
+
+	if c.JWT.KeysFile == "" {
+		if c.JWT.Secret == "" {
+			problems = append(problems, "JWT_SECRET or JWT_KEYS_FILE is required")
+		} else if len(c.JWT.Secret) < token.MinHMACSecretLength {
+			problems = append(problems, fmt.Sprintf("JWT_SECRET must be at least %d bytes", token.MinHMACSecretLength))
+		}
+	}
+
+	if _, err := c.Cookie.Options(); err != nil {
+		problems = append(problems, "cookie: "+err.Error())
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("unknown log level %q, expected debug, info, warn or error", c.Log.Level))
+	}
+
+	switch c.SMS.Sender {
+	case "console":
+	case "file":
+		required("SMS_FILE_PATH", c.SMS.FilePath)
+	default:
+		problems = append(problems, fmt.Sprintf("unknown SMS sender %q, expected console or file", c.SMS.Sender))
+	}
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// MySQLConfig returns the connection settings of one of the pools.
+func (c DatabaseConfig) MySQLConfig(pool PoolConfig) (*datasource.MySQLConfig, error) {
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return &datasource.MySQLConfig{
+		User:            pool.User,
+		Passwd:          pool.Password,
+		Host:            pool.Host,
+		Port:            pool.Port,
+		DBName:          pool.Name,
+		MaxOpenConns:    pool.MaxOpenConns,
+		MaxIdleConns:    pool.MaxIdleConns,
+		ConnMaxLifetime: pool.ConnMaxLifetime,
+		ConnMaxIdleTime: pool.ConnMaxIdleTime,
+		Location:        location,
+		QueryTimeout:    c.QueryTimeout,
+	}, nil
+}
+
+// Options returns the attributes of the auth cookies.
+func (c CookieConfig) Options() (cookie.Options, error) {
+	sameSite, err := cookie.ParseSameSite(c.SameSite)
+	if err != nil {
+		return cookie.Options{}, err
+	}
+	options := cookie.Options{Secure: c.Secure, Domain: c.Domain, SameSite: sameSite}
+	if err := cookie.ValidateOptions(options); err != nil {
+		return cookie.Options{}, err
+	}
+	return options, nil
+}
+
+// KeySet returns the token signing keys from KeysFile, falling back to a
+// single HS256 key made of Secret.
+func (c JWTConfig) KeySet() (*token.KeySet, error) {
+	if c.KeysFile != "" {
+		return token.LoadKeySet(c.KeysFile)
+	}
+	return token.NewHMACKeySet([]byte(c.Secret))
+}
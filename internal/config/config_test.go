@@ -0,0 +1,135 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() *Config {
+	config := Default()
+	for _, pool := range []*PoolConfig{&config.Database.Writer, &config.Database.Reader} {
+		pool.Host = "localhost"
+		pool.Name = "store_mgmt"
+		pool.User = "store_mgmt_admin"
+	}
+	config.JWT.Secret = "secret-of-at-least-thirty-two-bytes"
+	return config
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  addr: ":9000"
+  trusted_proxies: ["10.0.0.1"]
+database:
+  query_timeout: 2s
+  writer:
+    host: yaml-writer
+    max_open_conns: 50
+jwt:
+  secret: from-yaml
+repository: memory
+`), 0o600))
+	t.Setenv("DB_WRITER_HOST", "env-writer")
+	t.Setenv("DB_WRITER_CONN_MAX_LIFETIME", "10m")
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.2, ,10.0.0.3")
+	t.Setenv("SERVER_SHUTDOWN_TIMEOUT", "45s")
+	t.Setenv("SERVER_DRAIN_DELAY", "0s")
+
+	config, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", path, "-addr", ":9100", "migrate", "up"})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9100", config.Server.Addr)
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.3"}, config.Server.TrustedProxies)
+	assert.Equal(t, 45*time.Second, config.Server.ShutdownTimeout)
+	assert.Zero(t, config.Server.DrainDelay)
+	assert.Equal(t, 15*time.Second, config.Server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, config.Database.QueryTimeout)
+	assert.Equal(t, "env-writer", config.Database.Writer.Host)
+	assert.Equal(t, 50, config.Database.Writer.MaxOpenConns)
+	assert.Equal(t, 10*time.Minute, config.Database.Writer.ConnMaxLifetime)
+	assert.Equal(t, 30, config.Database.Reader.MaxOpenConns)
+	assert.Equal(t, "from-yaml", config.JWT.Secret)
+	assert.Equal(t, "memory", config.Repository)
+}
+
+func TestReadEnv_ReportsEveryMalformedValue(t *testing.T) {
+	env := map[string]string{
+		"DB_READER_MAX_IDLE_CONNS": "many",
+		"DB_QUERY_TIMEOUT":         "5",
+		"COOKIE_SECURE":            "maybe",
+	}
+	err := Default().readEnv(func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+
+	var configErr *Error
+	require.ErrorAs(t, err, &configErr)
+	assert.Len(t, configErr.Problems, 3)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, validConfig().Validate())
+
+	config := Default()
+	config.Cookie.SameSite = "none"
+	config.SMS.Sender = "file"
+	config.Database.Writer.MaxIdleConns = 40
+	config.Log.Level = "loud"
+	config.Server.DrainDelay = -time.Second
+	err := config.Validate()
+
+	var configErr *Error
+	require.ErrorAs(t, err, &configErr)
+	assert.Contains(t, configErr.Problems, "DB_WRITER_HOST is required")
+	assert.Contains(t, configErr.Problems, "DB_READER_USER is required")
+	assert.Contains(t, configErr.Problems, "writer pool keeps more idle connections than it may open")
+	assert.Contains(t, configErr.Problems, "JWT_SECRET or JWT_KEYS_FILE is required")
+	assert.Contains(t, configErr.Problems, "cookie: SameSite=None requires secure cookies")
+	assert.Contains(t, configErr.Problems, "SMS_FILE_PATH is required")
+	assert.Contains(t, configErr.Problems, `unknown log level "loud", expected debug, info, warn or error`)
+	assert.Contains(t, configErr.Problems, "SERVER_DRAIN_DELAY must not be negative")
+}
+
+func TestValidate_MemoryNeedsNoDatabase(t *testing.T) {
+	config := Default()
+	config.Repository = "memory"
+	config.JWT.Secret = "secret-of-at-least-thirty-two-bytes"
+	assert.NoError(t, config.Validate())
+
+	config.Repository = "postgres"
+	assert.Error(t, config.Validate())
+}
+
+func TestValidate_ShortJWTSecret(t *testing.T) {
+	config := validConfig()
+	config.JWT.Secret = "nweiocujy4cf2178"
+
+	var configErr *Error
+	require.ErrorAs(t, config.Validate(), &configErr)
+	assert.Equal(t, []string{"JWT_SECRET must be at least 32 bytes"}, configErr.Problems)
+
+	_, err := config.JWT.KeySet()
+	assert.Error(t, err)
+}
+
+func TestMySQLConfig(t *testing.T) {
+	config := validConfig()
+	config.Database.Timezone = "UTC"
+
+	mysqlConfig, err := config.Database.MySQLConfig(config.Database.Writer)
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, mysqlConfig.Location)
+	assert.Equal(t, 30, mysqlConfig.MaxOpenConns)
+	assert.Equal(t, 10, mysqlConfig.MaxIdleConns)
+	assert.Equal(t, 5*time.Second, mysqlConfig.QueryTimeout)
+	assert.Contains(t, mysqlConfig.FormatDSN(), "time_zone=%27UTC%27")
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader copies set environment variables into the configuration,
+// collecting the ones that fail to parse instead of stopping at the first.
+type envReader struct {
+	lookup   func(string) (string, bool)
+	problems []string
+}
+
+func (r *envReader) value(key string) (string, bool) {
+	value, ok := r.lookup(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+func (r *envReader) fail(key, value string, err error) {
+	r.problems = append(r.problems, fmt.Sprintf("%s=%q: %v", key, value, err))
+}
+
+func (r *envReader) string(key string, dst *string) {
+	if value, ok := r.value(key); ok {
+		*dst = value
+	}
+}
+
+func (r *envReader) int(key string, dst *int) {
+	value, ok := r.value(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		r.fail(key, value, fmt.Errorf("not an integer"))
+		return
+	}
+	*dst = parsed
+}
+
+func (r *envReader) bool(key string, dst *bool) {
+	value, ok := r.value(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		r.fail(key, value, fmt.Errorf("not a boolean"))
+		return
+	}
+	*dst = parsed
+}
+
+func (r *envReader) duration(key string, dst *time.Duration) {
+	value, ok := r.value(key)
+	if !ok {
+		return
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		r.fail(key, value, fmt.Errorf("not a duration such as 5s"))
+		return
+	}
+	*dst = parsed
+}
+
+// list reads a comma separated list, skipping empty items.
+func (r *envReader) list(key string, dst *[]string) {
+	value, ok := r.value(key)
+	if !ok {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func (r *envReader) pool(prefix string, dst *PoolConfig) {
+	r.string(prefix+"HOST", &dst.Host)
+	r.string(prefix+"PORT", &dst.Port)
+	r.string(prefix+"DBNAME", &dst.Name)
+	r.string(prefix+"USER", &dst.User)
+	r.string(prefix+"PASS", &dst.Password)
+	r.int(prefix+"MAX_OPEN_CONNS", &dst.MaxOpenConns)
+	r.int(prefix+"MAX_IDLE_CONNS", &dst.MaxIdleConns)
+	r.duration(prefix+"CONN_MAX_LIFETIME", &dst.ConnMaxLifetime)
+	r.duration(prefix+"CONN_MAX_IDLE_TIME", &dst.ConnMaxIdleTime)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"store-management/internal/logging"
+	"store-management/internal/response"
+	"store-management/internal/service"
+	"time"
+)
+
+type AccountController interface {
+	Export(ctx *AuthContext)
+	Delete(ctx *AuthContext)
+}
+
+type accountController struct {
+	accountService service.AccountService
+}
+
+func NewAccountController(accountService service.AccountService) AccountController {
+	return &accountController{
+		accountService: accountService,
+	}
+}
+
+// Export streams the data of the user as a ZIP download. Once the archive
+// has started, a failure can only cut the download short.
+func (c *accountController) Export(ctx *AuthContext) {
+	filename := fmt.Sprintf("store-management-%d-%s.zip", ctx.User.ID, time.Now().Format("20060102"))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Header("Cache-Control", "no-store")
+
+	if err := c.accountService.Export(ctx.Request.Context(), ctx.User, ctx.Writer); err != nil {
+		if ctx.Writer.Written() {
+			slog.ErrorContext(ctx.Request.Context(), "failed to export user data", logging.Error(err))
+			ctx.Abort()
+			return
+		}
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.Error(err)
+	}
+}
+
+type deleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+func (c *accountController) Delete(ctx *AuthContext) {
+	var input deleteAccountInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	if err := c.accountService.DeleteAccount(ctx.Request.Context(), ctx.User, input.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidPassword) {
+			response.JSON(ctx, http.StatusForbidden, response.New(http.StatusForbidden, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+	clearAuthCookies(ctx.Context)
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"store-management/internal/permission"
+	"store-management/internal/response"
+	"store-management/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController interface {
+	List(ctx *AuthContext)
+	Create(ctx *AuthContext)
+	Get(ctx *AuthContext)
+	Update(ctx *AuthContext)
+	Delete(ctx *AuthContext)
+}
+
+type apiKeyController struct {
+	storeService  service.StoreService
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(storeService service.StoreService, apiKeyService service.APIKeyService) APIKeyController {
+	return &apiKeyController{
+		storeService:  storeService,
+		apiKeyService: apiKeyService,
+	}
+}
+
+type apiKeyUriInput struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
+type createAPIKeyInput struct {
+	Name      string   `json:"name" binding:"required"`
+	Scopes    []string `json:"scopes" binding:"required"`
+	ExpiresAt *int64   `json:"expires_at"`
+}
+
+type updateAPIKeyInput struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt *int64   `json:"expires_at"`
+}
+
+func unixTimePtr(value *int64) *time.Time {
+	if value == nil {
+		return nil
+	}
+	t := time.Unix(*value, 0)
+	return &t
+}
+
+func (c *apiKeyController) List(ctx *AuthContext) {
+	access := resolveAccess(ctx, c.storeService, permission.APIKeyManage)
+	if access == nil {
+		return
+	}
+	store := access.Store
+
+	keys, err := c.apiKeyService.ListAPIKeys(ctx.Request.Context(), store.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
+		"items": keys,
+	}))
+}
+
+func (c *apiKeyController) Create(ctx *AuthContext) {
+	var input createAPIKeyInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.APIKeyManage)
+	if access == nil {
+		return
+	}
+	store := access.Store
+
+	key, rawKey, err := c.apiKeyService.CreateAPIKey(ctx.Request.Context(), store.ID, input.Name, input.Scopes, unixTimePtr(input.ExpiresAt))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidScope) {
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusCreated, response.New(http.StatusCreated, response.MessageOK, gin.H{
+		"api_key": key,
+		"key":     rawKey,
+	}))
+}
+
+func (c *apiKeyController) Get(ctx *AuthContext) {
+	var uriInput apiKeyUriInput
+	if err := ctx.ShouldBindUri(&uriInput); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.APIKeyManage)
+	if access == nil {
+		return
+	}
+	store := access.Store
+
+	key, err := c.apiKeyService.GetAPIKey(ctx.Request.Context(), store.ID, uriInput.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, key))
+}
+
+func (c *apiKeyController) Update(ctx *AuthContext) {
+	var uriInput apiKeyUriInput
+	var input updateAPIKeyInput
+
+	if err := ctx.ShouldBindUri(&uriInput); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.APIKeyManage)
+	if access == nil {
+		return
+	}
+	store := access.Store
+
+	key, err := c.apiKeyService.GetAPIKey(ctx.Request.Context(), store.ID, uriInput.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	if input.Name != "" {
+		key.Name = input.Name
+	}
+	if input.Scopes != nil {
+		key.Scopes = input.Scopes
+	}
+	if input.ExpiresAt != nil {
+		key.ExpiresAt = unixTimePtr(input.ExpiresAt)
+	}
+
+	if err := c.apiKeyService.UpdateAPIKey(ctx.Request.Context(), key); err != nil {
+		if errors.Is(err, service.ErrInvalidScope) {
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, key))
+}
+
+func (c *apiKeyController) Delete(ctx *AuthContext) {
+	var uriInput apiKeyUriInput
+	if err := ctx.ShouldBindUri(&uriInput); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.APIKeyManage)
+	if access == nil {
+		return
+	}
+	store := access.Store
+
+	if err := c.apiKeyService.DeleteAPIKey(ctx.Request.Context(), store.ID, uriInput.ID); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
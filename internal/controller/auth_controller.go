@@ -2,102 +2,507 @@ package controller
 
 import (
 	"errors"
+	"math"
 	"net/http"
-	"os"
+	"store-management/internal/cookie"
+	"store-management/internal/model"
+	"store-management/internal/phone"
 	"store-management/internal/response"
 	"store-management/internal/service"
+	"store-management/internal/token"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthController interface {
+	SendPhoneCode(ctx *gin.Context)
+	VerifyPhoneCode(ctx *gin.Context)
 	Register(ctx *gin.Context)
 	Login(ctx *gin.Context)
+	LoginTwoFactor(ctx *gin.Context)
 	Logout(ctx *gin.Context)
+	Refresh(ctx *gin.Context)
+	ChangePassword(ctx *AuthContext)
+	SendPasswordResetCode(ctx *gin.Context)
+	VerifyPasswordResetCode(ctx *gin.Context)
+	ResetPassword(ctx *gin.Context)
+	JWKS(ctx *gin.Context)
 }
 
 type authController struct {
-	authService service.AuthService
+	authService         service.AuthService
+	verificationService service.VerificationService
+	twoFactorService    service.TwoFactorService
 }
 
-func NewAuthController(authService service.AuthService) AuthController {
+func NewAuthController(authService service.AuthService, verificationService service.VerificationService, twoFactorService service.TwoFactorService) AuthController {
 	return authController{
-		authService: authService,
+		authService:         authService,
+		verificationService: verificationService,
+		twoFactorService:    twoFactorService,
 	}
 }
 
-type registerInput struct {
+type sendPhoneCodeInput struct {
 	PhoneNumber string `json:"phone_number" binding:"required"`
-	Password    string `json:"password" binding:"required"`
+}
+
+func (c authController) SendPhoneCode(ctx *gin.Context) {
+	var input sendPhoneCodeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	err := c.verificationService.SendCode(ctx.Request.Context(), model.VerificationPurposeRegister, input.PhoneNumber)
+	if err != nil {
+		respondSendCodeError(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusAccepted, response.New(http.StatusAccepted, response.MessageOK, nil))
+}
+
+func respondSendCodeError(ctx *gin.Context, err error) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.Is(err, phone.ErrInvalidPhoneNumber):
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+	case errors.As(err, &rateLimitErr):
+		respondTooManyRequests(ctx, rateLimitErr)
+	default:
+		ctx.Error(err)
+	}
+}
+
+// respondTooManyRequests responds 429 with the number of seconds to wait, rounded
+// up, in Retry-After.
+func respondTooManyRequests(ctx *gin.Context, err *service.RateLimitError) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	response.JSON(ctx, http.StatusTooManyRequests, response.New(http.StatusTooManyRequests, err.Error(), nil))
+}
+
+type verifyPhoneCodeInput struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+}
+
+type verifyPhoneCodeOutput struct {
+	VerificationToken string `json:"verification_token"`
+}
+
+func (c authController) VerifyPhoneCode(ctx *gin.Context) {
+	var input verifyPhoneCodeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	c.verifyCode(ctx, model.VerificationPurposeRegister, input)
+}
+
+func (c authController) verifyCode(ctx *gin.Context, purpose model.VerificationPurpose, input verifyPhoneCodeInput) {
+	verificationToken, err := c.verificationService.VerifyCode(ctx.Request.Context(), purpose, input.PhoneNumber, input.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, phone.ErrInvalidPhoneNumber), errors.Is(err, service.ErrInvalidVerificationCode):
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+		case errors.Is(err, service.ErrTooManyAttempts):
+			response.JSON(ctx, http.StatusTooManyRequests, response.New(http.StatusTooManyRequests, err.Error(), nil))
+		default:
+			ctx.Error(err)
+		}
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, verifyPhoneCodeOutput{VerificationToken: verificationToken}))
+}
+
+type registerInput struct {
+	PhoneNumber       string `json:"phone_number" binding:"required"`
+	Password          string `json:"password" binding:"required"`
+	VerificationToken string `json:"verification_token" binding:"required"`
 }
 
 func (c authController) Register(ctx *gin.Context) {
 	var input registerInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 
-	err := c.authService.Register(input.PhoneNumber, input.Password)
+	err := c.authService.Register(ctx.Request.Context(), input.PhoneNumber, input.Password, input.VerificationToken)
 
 	if err != nil {
 		if errors.Is(err, service.ErrDuplicateUser) {
-			ctx.JSON(http.StatusConflict, response.New(http.StatusConflict, err.Error(), nil))
+			response.JSON(ctx, http.StatusConflict, response.New(http.StatusConflict, err.Error(), nil))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		if errors.Is(err, phone.ErrInvalidPhoneNumber) {
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			response.JSON(ctx, http.StatusForbidden, response.New(http.StatusForbidden, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, response.New(http.StatusCreated, response.MessageOK, nil))
+	response.JSON(ctx, http.StatusCreated, response.New(http.StatusCreated, response.MessageOK, nil))
 }
 
 type loginInput struct {
 	PhoneNumber string `json:"phone_number" binding:"required"`
 	Password    string `json:"password" binding:"required"`
+	// ReturnToken asks for the tokens in the response body instead of cookies,
+	// for clients that authenticate with the Authorization header.
+	ReturnToken bool `json:"return_token"`
+	// DeviceLabel names the device in the session list, e.g. "counter tablet".
+	DeviceLabel string `json:"device_label"`
+}
+
+func sessionClient(ctx *gin.Context, deviceLabel string) *model.SessionClient {
+	return &model.SessionClient{
+		DeviceLabel: deviceLabel,
+		UserAgent:   ctx.Request.UserAgent(),
+		IP:          ctx.ClientIP(),
+	}
+}
+
+type tokenOutput struct {
+	AccessToken           string `json:"access_token"`
+	TokenType             string `json:"token_type"`
+	ExpiresIn             int64  `json:"expires_in"`
+	RefreshToken          string `json:"refresh_token"`
+	RefreshTokenExpiresIn int64  `json:"refresh_token_expires_in"`
+}
+
+func newTokenOutput(tokens *service.AuthTokens) tokenOutput {
+	return tokenOutput{
+		AccessToken:           tokens.AccessToken,
+		TokenType:             "Bearer",
+		ExpiresIn:             int64(time.Until(tokens.AccessTokenExpiresAt).Seconds()),
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresIn: int64(time.Until(tokens.RefreshTokenExpiresAt).Seconds()),
+	}
 }
 
 func (c authController) Login(ctx *gin.Context) {
 	var input loginInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 
-	user, err := c.authService.Login(input.PhoneNumber, input.Password)
+	user, err := c.authService.Login(ctx.Request.Context(), input.PhoneNumber, input.Password, ctx.ClientIP())
 
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, err.Error(), nil))
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, err.Error(), nil))
+			return
+		}
+		var rateLimitErr *service.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondTooManyRequests(ctx, rateLimitErr)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		ctx.Error(err)
+		return
+	}
+
+	client := sessionClient(ctx, input.DeviceLabel)
+	enabled, err := c.twoFactorService.IsEnabled(ctx.Request.Context(), user.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if enabled {
+		challengeToken, expiresAt, err := c.twoFactorService.CreateLoginChallenge(ctx.Request.Context(), user.ID, client, input.ReturnToken)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, loginChallengeOutput{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresIn:         int64(time.Until(expiresAt).Seconds()),
+		}))
+		return
+	}
+
+	c.completeLogin(ctx, user.ID, client, input.ReturnToken)
+}
+
+// completeLogin starts the session of a login that passed every factor.
+func (c authController) completeLogin(ctx *gin.Context, userId int64, client *model.SessionClient, returnToken bool) {
+	if err := c.authService.CompleteLogin(ctx.Request.Context(), userId); err != nil {
+		ctx.Error(err)
+		return
+	}
+	c.startSession(ctx, userId, client, returnToken)
+}
+
+// startSession issues the tokens of a new session, in the response body when
+// returnToken is set and in cookies otherwise.
+func (c authController) startSession(ctx *gin.Context, userId int64, client *model.SessionClient, returnToken bool) {
+	tokens, err := c.authService.CreateSession(ctx.Request.Context(), userId, client)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if returnToken {
+		response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, newTokenOutput(tokens)))
+		return
+	}
+	if err := setAuthCookies(ctx, tokens); err != nil {
+		ctx.Error(err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+// loginChallengeOutput is returned by Login instead of tokens when the user
+// has two-factor authentication enabled.
+type loginChallengeOutput struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
+}
+
+type loginTwoFactorInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// LoginTwoFactor completes a login challenge with a TOTP or recovery code and
+// issues the tokens the way the original Login request asked for.
+func (c authController) LoginTwoFactor(ctx *gin.Context) {
+	var input loginTwoFactorInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	challenge, err := c.twoFactorService.VerifyLoginChallenge(ctx.Request.Context(), input.ChallengeToken, input.Code)
+	if err != nil {
+		var rateLimitErr *service.RateLimitError
+		switch {
+		case errors.Is(err, service.ErrInvalidLoginChallenge), errors.Is(err, service.ErrInvalidTwoFactorCode):
+			response.JSON(ctx, http.StatusUnauthorized, response.New(http.StatusUnauthorized, err.Error(), nil))
+		case errors.As(err, &rateLimitErr):
+			respondTooManyRequests(ctx, rateLimitErr)
+		default:
+			ctx.Error(err)
+		}
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": strconv.FormatInt(user.ID, 10),
-		"exp": time.Now().Add(time.Hour).Unix(),
-	})
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	c.completeLogin(ctx, challenge.UserID, &challenge.Client, challenge.ReturnToken)
+}
+
+const refreshCookiePath = "/v1/auth"
+
+// setAuthCookies also rotates the CSRF token that has to accompany unsafe
+// requests authenticated by these cookies, see middleware.CSRFMiddleware.
+func setAuthCookies(ctx *gin.Context, tokens *service.AuthTokens) error {
+	refreshMaxAge := int(time.Until(tokens.RefreshTokenExpiresAt).Seconds())
+	if _, err := cookie.SetCSRFToken(ctx.Writer, refreshMaxAge); err != nil {
+		return err
+	}
+	cookie.Set(ctx.Writer, token.CookieName, tokens.AccessToken, int(time.Until(tokens.AccessTokenExpiresAt).Seconds()), "/", true)
+	cookie.Set(ctx.Writer, token.RefreshCookieName, tokens.RefreshToken, refreshMaxAge, refreshCookiePath, true)
+	return nil
+}
+
+func clearAuthCookies(ctx *gin.Context) {
+	cookie.Set(ctx.Writer, token.CookieName, "", -1, "/", true)
+	cookie.Set(ctx.Writer, token.RefreshCookieName, "", -1, refreshCookiePath, true)
+	cookie.Set(ctx.Writer, cookie.CSRFName, "", -1, "/", false)
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh reads the refresh token from the request body or, when the body
+// has none, from the refresh cookie. The new tokens are returned the same way
+// the refresh token was presented.
+func (c authController) Refresh(ctx *gin.Context) {
+	var input refreshInput
+	_ = ctx.ShouldBindJSON(&input)
 
+	refreshToken := input.RefreshToken
+	fromBody := refreshToken != ""
+	if !fromBody {
+		refreshToken, _ = ctx.Cookie(token.RefreshCookieName)
+	}
+	if refreshToken == "" {
+		response.JSON(ctx, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+		return
+	}
+
+	tokens, err := c.authService.Refresh(ctx.Request.Context(), refreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			if !fromBody {
+				clearAuthCookies(ctx)
+			}
+			response.JSON(ctx, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+			return
+		}
+		ctx.Error(err)
 		return
 	}
 
-	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie("auth_token", tokenString, int(time.Hour.Seconds()), "/", "", false, true)
-	ctx.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+	if fromBody {
+		response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, newTokenOutput(tokens)))
+		return
+	}
+	if err := setAuthCookies(ctx, tokens); err != nil {
+		ctx.Error(err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+type logoutInput struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 func (c authController) Logout(ctx *gin.Context) {
-	if authToken, err := ctx.Cookie("auth_token"); authToken != "" && err != nil {
-		_ = c.authService.Logout(authToken)
+	var input logoutInput
+	_ = ctx.ShouldBindJSON(&input)
+
+	if authToken, source := token.FromRequest(ctx.Request); source != token.SourceNone {
+		if claims, err := token.Parse(authToken); err == nil {
+			if err := c.authService.Logout(ctx.Request.Context(), claims); err != nil {
+				ctx.Error(err)
+				return
+			}
+		}
+	}
+
+	refreshToken := input.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = ctx.Cookie(token.RefreshCookieName)
+	}
+	if refreshToken != "" {
+		if err := c.authService.RevokeRefreshToken(ctx.Request.Context(), refreshToken); err != nil {
+			ctx.Error(err)
+			return
+		}
 	}
-	ctx.SetCookie("auth_token", "", -1, "/", "", false, true)
-	ctx.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+	clearAuthCookies(ctx)
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+type changePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword invalidates every session of the user, so the caller gets a
+// fresh one back, delivered the same way the access token was presented.
+func (c authController) ChangePassword(ctx *AuthContext) {
+	var input changePasswordInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	err := c.authService.ChangePassword(ctx.Request.Context(), ctx.User, input.CurrentPassword, input.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPassword) {
+			response.JSON(ctx, http.StatusForbidden, response.New(http.StatusForbidden, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	tokens, err := c.authService.CreateSession(ctx.Request.Context(), ctx.User.ID, sessionClient(ctx.Context, ""))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if _, source := token.FromRequest(ctx.Request); source != token.SourceCookie {
+		response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, newTokenOutput(tokens)))
+		return
+	}
+	if err := setAuthCookies(ctx.Context, tokens); err != nil {
+		ctx.Error(err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+func (c authController) SendPasswordResetCode(ctx *gin.Context) {
+	var input sendPhoneCodeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	if err := c.authService.SendPasswordResetCode(ctx.Request.Context(), input.PhoneNumber); err != nil {
+		respondSendCodeError(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusAccepted, response.New(http.StatusAccepted, response.MessageOK, nil))
+}
+
+func (c authController) VerifyPasswordResetCode(ctx *gin.Context) {
+	var input verifyPhoneCodeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	c.verifyCode(ctx, model.VerificationPurposePasswordReset, input)
+}
+
+type resetPasswordInput struct {
+	PhoneNumber       string `json:"phone_number" binding:"required"`
+	VerificationToken string `json:"verification_token" binding:"required"`
+	NewPassword       string `json:"new_password" binding:"required"`
+}
+
+func (c authController) ResetPassword(ctx *gin.Context) {
+	var input resetPasswordInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	err := c.authService.ResetPassword(ctx.Request.Context(), input.PhoneNumber, input.VerificationToken, input.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, phone.ErrInvalidPhoneNumber):
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+		case errors.Is(err, service.ErrInvalidVerificationToken):
+			response.JSON(ctx, http.StatusForbidden, response.New(http.StatusForbidden, err.Error(), nil))
+		case errors.Is(err, service.ErrUserNotFound):
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, err.Error(), nil))
+		default:
+			ctx.Error(err)
+		}
+		return
+	}
+
+	clearAuthCookies(ctx)
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+// JWKS serves the public keys access tokens can be verified with. It follows
+// RFC 7517 rather than the usual response envelope so that JWT libraries can
+// fetch it directly.
+func (c authController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, token.CurrentJWKS())
 }
@@ -1,12 +1,54 @@
 package controller
 
 import (
+	"errors"
+	"net/http"
 	"store-management/internal/model"
+	"store-management/internal/permission"
+	"store-management/internal/response"
+	"store-management/internal/service"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+// AuthContext carries the authenticated principal of a request: a user
+// logged in with a token, or a store-owned API key. SessionID is the refresh
+// token family of the user's access token.
 type AuthContext struct {
 	*gin.Context
-	User *model.User
+	User      *model.User
+	SessionID string
+	APIKey    *model.APIKey
+}
+
+// resolveAccess resolves the store the request acts on, taken from the
+// :storeId path parameter when the route has one, and checks that the
+// principal holds the required permission there. When it returns nil the
+// error response has already been written.
+func resolveAccess(ctx *AuthContext, storeService service.StoreService, required permission.Permission) *service.StoreAccess {
+	var storeId int64
+	if param := ctx.Param("storeId"); param != "" {
+		id, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || id <= 0 {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return nil
+		}
+		storeId = id
+	}
+
+	access, err := storeService.ResolveAccess(ctx.Request.Context(), ctx.User, ctx.APIKey, storeId)
+	if err != nil {
+		if errors.Is(err, service.ErrStoreNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return nil
+		}
+		ctx.Error(err)
+		return nil
+	}
+	if !access.Can(required) {
+		response.JSON(ctx, http.StatusForbidden, response.New(http.StatusForbidden, response.MessageForbidden, nil))
+		return nil
+	}
+	return access
 }
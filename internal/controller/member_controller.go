@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"store-management/internal/model"
+	"store-management/internal/permission"
+	"store-management/internal/phone"
+	"store-management/internal/response"
+	"store-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MemberController interface {
+	List(ctx *AuthContext)
+	Remove(ctx *AuthContext)
+	Invite(ctx *AuthContext)
+	ListInvitations(ctx *AuthContext)
+	CancelInvitation(ctx *AuthContext)
+	ListMyInvitations(ctx *AuthContext)
+	AcceptInvitation(ctx *AuthContext)
+}
+
+type memberController struct {
+	storeService  service.StoreService
+	memberService service.MemberService
+}
+
+func NewMemberController(storeService service.StoreService, memberService service.MemberService) MemberController {
+	return &memberController{
+		storeService:  storeService,
+		memberService: memberService,
+	}
+}
+
+type memberUriInput struct {
+	UserID int64 `uri:"userId" binding:"required"`
+}
+
+type invitationUriInput struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
+type inviteInput struct {
+	PhoneNumber string     `json:"phone_number" binding:"required"`
+	Role        model.Role `json:"role" binding:"required"`
+}
+
+func (c *memberController) List(ctx *AuthContext) {
+	access := resolveAccess(ctx, c.storeService, permission.MemberManage)
+	if access == nil {
+		return
+	}
+
+	members, err := c.memberService.ListMembers(ctx.Request.Context(), access.Store.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
+		"items": members,
+	}))
+}
+
+func (c *memberController) Remove(ctx *AuthContext) {
+	var uriInput memberUriInput
+	if err := ctx.ShouldBindUri(&uriInput); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.MemberManage)
+	if access == nil {
+		return
+	}
+
+	if err := c.memberService.RemoveMember(ctx.Request.Context(), access.Store.ID, uriInput.UserID); err != nil {
+		if errors.Is(err, service.ErrMemberNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		if errors.Is(err, service.ErrCannotRemoveOwner) {
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+func (c *memberController) Invite(ctx *AuthContext) {
+	var input inviteInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.MemberManage)
+	if access == nil {
+		return
+	}
+
+	invitation, err := c.memberService.Invite(ctx.Request.Context(), access.Store.ID, ctx.User.ID, input.PhoneNumber, input.Role)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRole) || errors.Is(err, phone.ErrInvalidPhoneNumber) {
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+		if errors.Is(err, service.ErrAlreadyMember) {
+			response.JSON(ctx, http.StatusConflict, response.New(http.StatusConflict, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusCreated, response.New(http.StatusCreated, response.MessageOK, invitation))
+}
+
+func (c *memberController) ListInvitations(ctx *AuthContext) {
+	access := resolveAccess(ctx, c.storeService, permission.MemberManage)
+	if access == nil {
+		return
+	}
+
+	invitations, err := c.memberService.ListInvitations(ctx.Request.Context(), access.Store.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
+		"items": invitations,
+	}))
+}
+
+func (c *memberController) CancelInvitation(ctx *AuthContext) {
+	var uriInput invitationUriInput
+	if err := ctx.ShouldBindUri(&uriInput); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.MemberManage)
+	if access == nil {
+		return
+	}
+
+	if err := c.memberService.CancelInvitation(ctx.Request.Context(), access.Store.ID, uriInput.ID); err != nil {
+		if errors.Is(err, service.ErrInvitationNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+func (c *memberController) ListMyInvitations(ctx *AuthContext) {
+	invitations, err := c.memberService.ListMyInvitations(ctx.Request.Context(), ctx.User)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
+		"items": invitations,
+	}))
+}
+
+func (c *memberController) AcceptInvitation(ctx *AuthContext) {
+	var uriInput invitationUriInput
+	if err := ctx.ShouldBindUri(&uriInput); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	if err := c.memberService.AcceptInvitation(ctx.Request.Context(), ctx.User, uriInput.ID); err != nil {
+		if errors.Is(err, service.ErrInvitationNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		if errors.Is(err, service.ErrAlreadyMember) {
+			response.JSON(ctx, http.StatusConflict, response.New(http.StatusConflict, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
@@ -1,10 +1,10 @@
 package controller
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 	"store-management/internal/model"
+	"store-management/internal/permission"
 	"store-management/internal/response"
 	"store-management/internal/service"
 	"strconv"
@@ -36,13 +36,39 @@ type createInput struct {
 	Category    string  `json:"category" binding:"required"`
 	Name        string  `json:"name" binding:"required"`
 	Price       float64 `json:"price" binding:"required"`
-	Cost        float64 `json:"cost" binding:"required"`
+	Cost        float64 `json:"cost"`
 	Description string  `json:"description" binding:"required"`
 	Barcode     string  `json:"barcode" binding:"required"`
 	ExpiryDate  int64   `json:"expiry_date" binding:"required"`
 	Size        string  `json:"size" binding:"required"`
 }
 
+// productWithoutCost hides the cost of a product from principals that may
+// not see it. Its Cost field is shallower than the embedded one, so
+// encoding/json uses it instead and omits it while nil.
+type productWithoutCost struct {
+	*model.Product
+	Cost *float64 `json:"cost,omitempty"`
+}
+
+func presentProduct(product *model.Product, access *service.StoreAccess) interface{} {
+	if access.Can(permission.ProductViewCost) {
+		return product
+	}
+	return productWithoutCost{Product: product}
+}
+
+func presentProducts(products []*model.Product, access *service.StoreAccess) interface{} {
+	if access.Can(permission.ProductViewCost) {
+		return products
+	}
+	presented := make([]productWithoutCost, 0, len(products))
+	for _, product := range products {
+		presented = append(presented, productWithoutCost{Product: product})
+	}
+	return presented
+}
+
 type getUriInput struct {
 	ID int64 `uri:"id" binding:"required"`
 }
@@ -51,48 +77,45 @@ func (c *productController) Get(ctx *AuthContext) {
 	var input getUriInput
 
 	if err := ctx.ShouldBindUri(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 
-	store, err := c.storeService.GetStoreByUserID(ctx.User.ID)
-	if err != nil {
-		if errors.Is(err, service.ErrStoreNotFound) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+	access := resolveAccess(ctx, c.storeService, permission.ProductRead)
+	if access == nil {
 		return
 	}
+	store := access.Store
 
-	product, err := c.storeService.GetProduct(store.ID, input.ID)
+	product, err := c.storeService.GetProduct(ctx.Request.Context(), store.ID, input.ID)
 	if err != nil {
-		if errors.Is(err, service.ErrProductNotFound) || errors.Is(err, sql.ErrNoRows) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+		if errors.Is(err, service.ErrProductNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, product))
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, presentProduct(product, access)))
 
 }
 
 func (c *productController) Create(ctx *AuthContext) {
 	var input createInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 
-	store, err := c.storeService.GetStoreByUserID(ctx.User.ID)
-	if err != nil {
-		if errors.Is(err, service.ErrStoreNotFound) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, err.Error(), nil))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+	access := resolveAccess(ctx, c.storeService, permission.ProductCreate)
+	if access == nil {
+		return
+	}
+	store := access.Store
+
+	if input.Cost != 0 && !access.Can(permission.ProductViewCost) {
+		response.JSON(ctx, http.StatusForbidden, response.New(http.StatusForbidden, response.MessageForbidden, nil))
 		return
 	}
 
@@ -107,10 +130,10 @@ func (c *productController) Create(ctx *AuthContext) {
 		Size:        input.Size,
 	}
 
-	if productId, err := c.storeService.CreateProduct(store.ID, product); err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+	if productId, err := c.storeService.CreateProduct(ctx.Request.Context(), store.ID, product); err != nil {
+		ctx.Error(err)
 	} else {
-		ctx.JSON(http.StatusCreated, response.New(http.StatusCreated, response.MessageOK, gin.H{
+		response.JSON(ctx, http.StatusCreated, response.New(http.StatusCreated, response.MessageOK, gin.H{
 			"id": productId,
 		}))
 	}
@@ -123,31 +146,26 @@ type deleteInput struct {
 func (c *productController) Delete(ctx *AuthContext) {
 	var input deleteInput
 	if err := ctx.ShouldBindUri(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 
-	store, err := c.storeService.GetStoreByUserID(ctx.User.ID)
-	if err != nil {
-		if errors.Is(err, service.ErrStoreNotFound) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+	access := resolveAccess(ctx, c.storeService, permission.ProductDelete)
+	if access == nil {
 		return
-
 	}
+	store := access.Store
 
-	if err := c.storeService.DeleteProduct(store.ID, input.ID); err != nil {
+	if err := c.storeService.DeleteProduct(ctx.Request.Context(), store.ID, input.ID); err != nil {
 		if errors.Is(err, service.ErrProductNotFound) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
 }
 
 type updateUriInput struct {
@@ -169,23 +187,23 @@ func (c *productController) Update(ctx *AuthContext) {
 	var input updateInput
 
 	if err := ctx.ShouldBindUri(&uriInput); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 
-	store, err := c.storeService.GetStoreByUserID(ctx.User.ID)
-	if err != nil {
-		if errors.Is(err, service.ErrStoreNotFound) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+	access := resolveAccess(ctx, c.storeService, permission.ProductUpdate)
+	if access == nil {
 		return
+	}
+	store := access.Store
 
+	if input.Cost != 0 && !access.Can(permission.ProductViewCost) {
+		response.JSON(ctx, http.StatusForbidden, response.New(http.StatusForbidden, response.MessageForbidden, nil))
+		return
 	}
 
 	product := &model.Product{
@@ -200,16 +218,16 @@ func (c *productController) Update(ctx *AuthContext) {
 		Size:        input.Size,
 	}
 
-	if err := c.storeService.UpdateProduct(store.ID, product); err != nil {
-		if errors.Is(err, service.ErrProductNotFound) || errors.Is(err, sql.ErrNoRows) {
-			ctx.JSON(http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+	if err := c.storeService.UpdateProduct(ctx.Request.Context(), store.ID, product); err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
 }
 
 const defaultLimit = 10
@@ -222,43 +240,43 @@ func (c *productController) List(ctx *AuthContext) {
 		cursor = 0
 	}
 
-	store, err := c.storeService.GetStoreByUserID(ctx.User.ID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+	access := resolveAccess(ctx, c.storeService, permission.ProductRead)
+	if access == nil {
 		return
 	}
+	store := access.Store
 
-	products, err := c.storeService.GetProductsWithPagination(store.ID, cursor, defaultLimit)
+	products, err := c.storeService.GetProductsWithPagination(ctx.Request.Context(), store.ID, cursor, defaultLimit)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
-		"items": products,
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
+		"items": presentProducts(products, access),
 	}))
 }
 
 func (c *productController) Search(ctx *AuthContext) {
 	query := ctx.Query("query")
 	if query == "" {
-		ctx.JSON(http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
 		return
 	}
 
-	store, err := c.storeService.GetStoreByUserID(ctx.User.ID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+	access := resolveAccess(ctx, c.storeService, permission.ProductRead)
+	if access == nil {
 		return
 	}
+	store := access.Store
 
-	products, err := c.storeService.SearchProducts(store.ID, query)
+	products, err := c.storeService.SearchProducts(ctx.Request.Context(), store.ID, query)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
-		"items": products,
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
+		"items": presentProducts(products, access),
 	}))
 }
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"store-management/internal/model"
+	"store-management/internal/permission"
+	"store-management/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// storeServiceStub implements the StoreService methods the product routes
+// use; any other method panics.
+type storeServiceStub struct {
+	service.StoreService
+	mock.Mock
+}
+
+func (s *storeServiceStub) ResolveAccess(ctx context.Context, user *model.User, apiKey *model.APIKey, storeId int64) (*service.StoreAccess, error) {
+	args := s.Called(storeId)
+	return args.Get(0).(*service.StoreAccess), args.Error(1)
+}
+
+func (s *storeServiceStub) CreateProduct(ctx context.Context, storeId int64, product *model.Product) (int64, error) {
+	args := s.Called(storeId, product)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestPresentProduct_HidesCostWithoutPermission(t *testing.T) {
+	product := &model.Product{ID: 1, Name: "아메리카노", Price: 4500, Cost: 1200}
+
+	staff := &service.StoreAccess{Permissions: permission.ForRole(model.RoleStaff)}
+	body, err := json.Marshal(presentProduct(product, staff))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "cost")
+	assert.Contains(t, string(body), `"price":4500`)
+
+	manager := &service.StoreAccess{Permissions: permission.ForRole(model.RoleManager)}
+	body, err = json.Marshal(presentProducts([]*model.Product{product}, manager))
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"cost":1200`)
+}
+
+func TestProductController_Create_CostNeedsPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"category":"drink","name":"아메리카노","price":4500,%s"description":"-","barcode":"880","expiry_date":1900000000,"size":"tall"}`
+
+	tests := []struct {
+		name        string
+		permissions permission.Set
+		cost        string
+		code        int
+	}{
+		{name: "manager sets cost", permissions: permission.ForRole(model.RoleManager), cost: `"cost":1200,`, code: http.StatusCreated},
+		{name: "write scope sets cost", permissions: permission.ForScopes(model.Scopes{model.ScopeProductsWrite}), cost: `"cost":1200,`, code: http.StatusForbidden},
+		{name: "write scope omits cost", permissions: permission.ForScopes(model.Scopes{model.ScopeProductsWrite}), code: http.StatusCreated},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storeService := &storeServiceStub{}
+			storeService.On("ResolveAccess", int64(1)).Return(&service.StoreAccess{Store: &model.Store{ID: 1}, Permissions: test.permissions}, nil)
+			storeService.On("CreateProduct", int64(1), mock.Anything).Return(int64(7), nil)
+			productController := NewProductController(storeService)
+
+			r := gin.New()
+			r.POST("/v1/stores/:storeId/products", func(c *gin.Context) {
+				productController.Create(&AuthContext{Context: c, User: &model.User{ID: 1}})
+			})
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/stores/1/products", strings.NewReader(fmt.Sprintf(body, test.cost))))
+
+			assert.Equal(t, test.code, rec.Code)
+			if test.code != http.StatusCreated {
+				storeService.AssertNotCalled(t, "CreateProduct", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"store-management/internal/response"
+	"store-management/internal/service"
+	"strconv"
+)
+
+type SessionController interface {
+	List(ctx *AuthContext)
+	Delete(ctx *AuthContext)
+}
+
+type sessionController struct {
+	sessionService service.SessionService
+}
+
+func NewSessionController(sessionService service.SessionService) SessionController {
+	return &sessionController{
+		sessionService: sessionService,
+	}
+}
+
+func (c *sessionController) List(ctx *AuthContext) {
+	sessions, err := c.sessionService.ListSessions(ctx.Request.Context(), ctx.User.ID, ctx.SessionID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, sessions))
+}
+
+// othersSessionID is the :id that revokes every session but the current one.
+const othersSessionID = "others"
+
+func (c *sessionController) Delete(ctx *AuthContext) {
+	if ctx.Param("id") == othersSessionID {
+		if err := c.sessionService.RevokeOtherSessions(ctx.Request.Context(), ctx.User.ID, ctx.SessionID); err != nil {
+			ctx.Error(err)
+			return
+		}
+		response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+		return
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	if err := c.sessionService.RevokeSession(ctx.Request.Context(), ctx.User.ID, id); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"store-management/internal/model"
+	"store-management/internal/permission"
+	"store-management/internal/response"
+	"store-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StoreController interface {
+	List(ctx *AuthContext)
+	Create(ctx *AuthContext)
+	Get(ctx *AuthContext)
+	Update(ctx *AuthContext)
+	Delete(ctx *AuthContext)
+}
+
+type storeController struct {
+	storeService service.StoreService
+}
+
+func NewStoreController(storeService service.StoreService) StoreController {
+	return &storeController{
+		storeService: storeService,
+	}
+}
+
+type createStoreInput struct {
+	Name          string              `json:"name" binding:"required"`
+	Address       string              `json:"address"`
+	Phone         string              `json:"phone"`
+	BusinessHours model.BusinessHours `json:"business_hours"`
+	Timezone      string              `json:"timezone"`
+}
+
+type updateStoreInput struct {
+	Name          *string             `json:"name"`
+	Address       *string             `json:"address"`
+	Phone         *string             `json:"phone"`
+	BusinessHours model.BusinessHours `json:"business_hours"`
+	Timezone      *string             `json:"timezone"`
+}
+
+func (c *storeController) List(ctx *AuthContext) {
+	stores, err := c.storeService.ListStores(ctx.Request.Context(), ctx.User.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, gin.H{
+		"items": stores,
+	}))
+}
+
+func (c *storeController) Create(ctx *AuthContext) {
+	var input createStoreInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	store, err := c.storeService.CreateStore(ctx.Request.Context(), &model.Store{
+		UserID:        ctx.User.ID,
+		Name:          input.Name,
+		Address:       input.Address,
+		Phone:         input.Phone,
+		BusinessHours: input.BusinessHours,
+		Timezone:      input.Timezone,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStore) {
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	store.Role = model.RoleOwner
+	response.JSON(ctx, http.StatusCreated, response.New(http.StatusCreated, response.MessageOK, store))
+}
+
+func (c *storeController) Get(ctx *AuthContext) {
+	access := resolveAccess(ctx, c.storeService, permission.StoreRead)
+	if access == nil {
+		return
+	}
+
+	store, err := c.storeService.GetStore(ctx.Request.Context(), access.Store.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrStoreNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	store.Role = access.Role
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, store))
+}
+
+func (c *storeController) Update(ctx *AuthContext) {
+	var input updateStoreInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	access := resolveAccess(ctx, c.storeService, permission.StoreUpdate)
+	if access == nil {
+		return
+	}
+
+	store, err := c.storeService.GetStore(ctx.Request.Context(), access.Store.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrStoreNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	if input.Name != nil {
+		store.Name = *input.Name
+	}
+	if input.Address != nil {
+		store.Address = *input.Address
+	}
+	if input.Phone != nil {
+		store.Phone = *input.Phone
+	}
+	if input.BusinessHours != nil {
+		store.BusinessHours = input.BusinessHours
+	}
+	if input.Timezone != nil {
+		store.Timezone = *input.Timezone
+	}
+
+	if err := c.storeService.UpdateStore(ctx.Request.Context(), store); err != nil {
+		if errors.Is(err, service.ErrInvalidStore) {
+			response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+		if errors.Is(err, service.ErrStoreNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	store.Role = access.Role
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, store))
+}
+
+func (c *storeController) Delete(ctx *AuthContext) {
+	access := resolveAccess(ctx, c.storeService, permission.StoreDelete)
+	if access == nil {
+		return
+	}
+
+	if err := c.storeService.DeleteStore(ctx.Request.Context(), access.Store.ID); err != nil {
+		if errors.Is(err, service.ErrStoreNotFound) {
+			response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, response.MessageNotFound, nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
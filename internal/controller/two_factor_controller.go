@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"store-management/internal/response"
+	"store-management/internal/service"
+)
+
+type TwoFactorController interface {
+	Status(ctx *AuthContext)
+	Enroll(ctx *AuthContext)
+	Confirm(ctx *AuthContext)
+	Disable(ctx *AuthContext)
+}
+
+type twoFactorController struct {
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorController(twoFactorService service.TwoFactorService) TwoFactorController {
+	return &twoFactorController{
+		twoFactorService: twoFactorService,
+	}
+}
+
+type twoFactorStatusOutput struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (c *twoFactorController) Status(ctx *AuthContext) {
+	enabled, err := c.twoFactorService.IsEnabled(ctx.Request.Context(), ctx.User.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, twoFactorStatusOutput{Enabled: enabled}))
+}
+
+// Enroll returns a new TOTP secret and its provisioning URI, which clients
+// show as a QR code for authenticator apps to scan.
+func (c *twoFactorController) Enroll(ctx *AuthContext) {
+	enrollment, err := c.twoFactorService.BeginEnrollment(ctx.Request.Context(), ctx.User)
+	if err != nil {
+		if errors.Is(err, service.ErrTwoFactorAlreadyEnabled) {
+			response.JSON(ctx, http.StatusConflict, response.New(http.StatusConflict, err.Error(), nil))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, enrollment))
+}
+
+type twoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type recoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (c *twoFactorController) Confirm(ctx *AuthContext) {
+	var input twoFactorCodeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	recoveryCodes, err := c.twoFactorService.ConfirmEnrollment(ctx.Request.Context(), ctx.User, input.Code)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, recoveryCodesOutput{RecoveryCodes: recoveryCodes}))
+}
+
+func (c *twoFactorController) Disable(ctx *AuthContext) {
+	var input twoFactorCodeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, response.MessageInvalidInput, nil))
+		return
+	}
+
+	if err := c.twoFactorService.Disable(ctx.Request.Context(), ctx.User, input.Code); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	response.JSON(ctx, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+}
+
+func (c *twoFactorController) respondError(ctx *AuthContext, err error) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		response.JSON(ctx, http.StatusBadRequest, response.New(http.StatusBadRequest, err.Error(), nil))
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		response.JSON(ctx, http.StatusNotFound, response.New(http.StatusNotFound, err.Error(), nil))
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		response.JSON(ctx, http.StatusConflict, response.New(http.StatusConflict, err.Error(), nil))
+	case errors.As(err, &rateLimitErr):
+		respondTooManyRequests(ctx.Context, rateLimitErr)
+	default:
+		ctx.Error(err)
+	}
+}
@@ -0,0 +1,92 @@
+package cookie
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// CSRFName is the cookie holding the CSRF token, which clients echo in the
+// X-CSRF-Token header. It is readable by scripts on purpose.
+const CSRFName = "csrf_token"
+
+// Options are the attributes shared by every cookie the server sets.
+type Options struct {
+	Secure   bool
+	Domain   string
+	SameSite http.SameSite
+}
+
+var DefaultOptions = Options{SameSite: http.SameSiteLaxMode}
+
+var options atomic.Pointer[Options]
+
+// SetOptions replaces the cookie attributes after validating them.
+func SetOptions(o Options) error {
+	if err := ValidateOptions(o); err != nil {
+		return err
+	}
+	options.Store(&o)
+	return nil
+}
+
+// ValidateOptions checks that browsers will accept cookies with the given
+// attributes. SameSite=None is only accepted on secure cookies.
+func ValidateOptions(o Options) error {
+	if o.SameSite == http.SameSiteNoneMode && !o.Secure {
+		return fmt.Errorf("SameSite=None requires secure cookies")
+	}
+	return nil
+}
+
+func currentOptions() Options {
+	if o := options.Load(); o != nil {
+		return *o
+	}
+	return DefaultOptions
+}
+
+// ParseSameSite parses "lax", "strict" or "none"; empty means lax.
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unknown SameSite mode %q", value)
+	}
+}
+
+// Set sets a cookie with the configured attributes. A negative maxAge
+// deletes the cookie.
+func Set(w http.ResponseWriter, name, value string, maxAge int, path string, httpOnly bool) {
+	o := currentOptions()
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   o.Domain,
+		Secure:   o.Secure,
+		HttpOnly: httpOnly,
+		SameSite: o.SameSite,
+	})
+}
+
+// SetCSRFToken sets a new CSRF token cookie living as long as maxAge and
+// returns the token.
+func SetCSRFToken(w http.ResponseWriter, maxAge int) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	csrfToken := base64.RawURLEncoding.EncodeToString(b)
+	Set(w, CSRFName, csrfToken, maxAge, "/", false)
+	return csrfToken, nil
+}
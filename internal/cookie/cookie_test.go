@@ -0,0 +1,40 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSameSite(t *testing.T) {
+	mode, err := ParseSameSite("")
+	require.NoError(t, err)
+	assert.Equal(t, http.SameSiteLaxMode, mode)
+
+	mode, err = ParseSameSite("Strict")
+	require.NoError(t, err)
+	assert.Equal(t, http.SameSiteStrictMode, mode)
+
+	_, err = ParseSameSite("always")
+	assert.Error(t, err)
+}
+
+func TestSetOptions(t *testing.T) {
+	defer options.Store(nil)
+
+	assert.Error(t, SetOptions(Options{SameSite: http.SameSiteNoneMode}))
+	require.NoError(t, SetOptions(Options{Secure: true, Domain: "example.com", SameSite: http.SameSiteNoneMode}))
+
+	rec := httptest.NewRecorder()
+	Set(rec, "name", "value", 60, "/", true)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].Secure)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, "example.com", cookies[0].Domain)
+	assert.Equal(t, http.SameSiteNoneMode, cookies[0].SameSite)
+}
@@ -5,6 +5,21 @@ import "time"
 type Cache interface {
 	Set(string, interface{}, time.Duration) error
 	Get(string) (interface{}, error)
+	// Update atomically replaces the value of key with the one fn returns
+	// for the current value, nil when key is missing or expired, and keeps
+	// it for the returned ttl. Nothing changes when fn fails.
+	Update(key string, fn func(value interface{}) (interface{}, time.Duration, error)) error
 	Clear()
 	Invalidate(string)
+	// Close releases the cache once the server no longer uses it.
+	Close() error
+	Stats() CacheStats
+}
+
+// CacheStats counts what happened to a cache since it was created. An
+// eviction is an item dropped because it expired.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
 }
@@ -1,7 +1,9 @@
 package datasource
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,9 +15,13 @@ type Item struct {
 type InMemoryCache struct {
 	data sync.Map
 	mu   sync.RWMutex
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
 }
 
-func NewInMemoryCache() Cache {
+func NewInMemoryCache() *InMemoryCache {
 	return &InMemoryCache{
 		data: sync.Map{},
 		mu:   sync.RWMutex{},
@@ -39,22 +45,52 @@ func (c *InMemoryCache) Get(key string) (interface{}, error) {
 
 	item, ok := c.data.Load(key)
 	if !ok {
+		c.misses.Add(1)
 		return nil, nil
 	}
 
 	if item.(Item).expiration > time.Now().UnixNano() {
+		c.hits.Add(1)
 		return item.(Item).value, nil
 	}
 
+	c.misses.Add(1)
 	c.mu.RUnlock()
 	c.mu.Lock()
-	c.data.Delete(key)
+	// The item may have been replaced while the lock was released.
+	if current, ok := c.data.Load(key); ok && current.(Item).expiration <= time.Now().UnixNano() {
+		c.data.Delete(key)
+		c.evictions.Add(1)
+	}
 	c.mu.Unlock()
 	c.mu.RLock()
 
 	return nil, nil
 }
 
+func (c *InMemoryCache) Update(key string, fn func(value interface{}) (interface{}, time.Duration, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var current interface{}
+	if item, ok := c.data.Load(key); ok && item.(Item).expiration > time.Now().UnixNano() {
+		c.hits.Add(1)
+		current = item.(Item).value
+	} else {
+		c.misses.Add(1)
+	}
+
+	value, ttl, err := fn(current)
+	if err != nil {
+		return err
+	}
+	c.data.Store(key, Item{
+		value:      value,
+		expiration: time.Now().Add(ttl).UnixNano(),
+	})
+	return nil
+}
+
 func (c *InMemoryCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -71,3 +107,45 @@ func (c *InMemoryCache) Invalidate(key string) {
 
 	c.data.Delete(key)
 }
+
+// RunJanitor deletes expired items every interval until ctx is done, so keys
+// that are never read again do not pile up.
+func (c *InMemoryCache) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+func (c *InMemoryCache) deleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	c.data.Range(func(key, value interface{}) bool {
+		if value.(Item).expiration <= now {
+			c.data.Delete(key)
+			c.evictions.Add(1)
+		}
+		return true
+	})
+}
+
+func (c *InMemoryCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+func (c *InMemoryCache) Close() error {
+	c.Clear()
+	return nil
+}
@@ -0,0 +1,75 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryCache_RunJanitor(t *testing.T) {
+	cache := NewInMemoryCache()
+	_ = cache.Set("expired", "value", time.Millisecond)
+	_ = cache.Set("alive", "value", time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cache.RunJanitor(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, ok := cache.data.Load("expired")
+		return !ok
+	}, time.Second, time.Millisecond)
+	_, ok := cache.data.Load("alive")
+	assert.True(t, ok)
+
+	cancel()
+	<-done
+}
+
+func TestInMemoryCache_Stats(t *testing.T) {
+	cache := NewInMemoryCache()
+	_ = cache.Set("alive", "value", time.Hour)
+	_ = cache.Set("expired", "value", -time.Second)
+
+	_, _ = cache.Get("alive")
+	_, _ = cache.Get("missing")
+	_, _ = cache.Get("expired")
+
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 2, Evictions: 1}, cache.Stats())
+}
+
+func TestInMemoryCache_Update(t *testing.T) {
+	cache := NewInMemoryCache()
+	increment := func(value interface{}) (interface{}, time.Duration, error) {
+		count, _ := value.(int)
+		return count + 1, time.Hour, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, cache.Update("count", increment))
+		}()
+	}
+	wg.Wait()
+
+	value, _ := cache.Get("count")
+	assert.Equal(t, 100, value)
+
+	failed := errors.New("failed")
+	err := cache.Update("count", func(value interface{}) (interface{}, time.Duration, error) {
+		return 0, time.Hour, failed
+	})
+	assert.ErrorIs(t, err, failed)
+	value, _ = cache.Get("count")
+	assert.Equal(t, 100, value)
+}
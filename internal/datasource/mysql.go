@@ -1,7 +1,13 @@
 package datasource
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"net"
+	"store-management/internal/apperror"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -9,27 +15,50 @@ import (
 )
 
 const (
-	MySQLDuplicateEntry = 1062
-	mysqlDefaultTimeout = time.Second * 30
-	mysqlDefaultMaxConn = 30
+	MySQLDuplicateEntry      = 1062
+	mysqlDefaultTimeout      = time.Second * 30
+	mysqlDefaultQueryTimeout = time.Second * 5
+	mysqlDefaultMaxOpenConns = 30
+	mysqlDefaultLocation     = "Asia/Seoul"
 )
 
+// MySQL is a connection pool whose errors carry an apperror.Kind, telling a
+// lost connection apart from a bad query. sql.ErrNoRows is returned as is.
+// Every statement is cut off after the configured query timeout, or earlier if
+// the caller's context is done. Statements given the context of a WithinTx run
+// in that transaction.
 type MySQL struct {
 	*sqlx.DB
+	queryTimeout time.Duration
 }
 
 type MySQLConfig struct {
-	User    string
-	Passwd  string
-	Host    string
-	Port    string
-	DBName  string
-	MaxConn int
-	Timeout time.Duration
+	User   string
+	Passwd string
+	Host   string
+	Port   string
+	DBName string
+	// MaxOpenConns, MaxIdleConns, ConnMaxLifetime and ConnMaxIdleTime size
+	// the connection pool as their sql.DB setters do. Zero keeps the
+	// sql.DB default, except for MaxOpenConns which defaults to 30.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// Location is the time zone of the session and of parsed times,
+	// Asia/Seoul when nil.
+	Location *time.Location
+	Timeout  time.Duration
+	// QueryTimeout bounds a single statement, including the ones run inside
+	// a transaction.
+	QueryTimeout time.Duration
 }
 
 func (c *MySQLConfig) FormatDSN() string {
-	loc, _ := time.LoadLocation("Asia/Seoul")
+	loc := c.Location
+	if loc == nil {
+		loc, _ = time.LoadLocation(mysqlDefaultLocation)
+	}
 
 	config := &mysql.Config{
 		User:                 c.User,
@@ -51,16 +80,133 @@ func (c *MySQLConfig) FormatDSN() string {
 	return config.FormatDSN()
 }
 
-func NewMySQL(config *MySQLConfig) *sqlx.DB {
-	if config.MaxConn == 0 {
-		config.MaxConn = mysqlDefaultMaxConn
+func NewMySQL(config *MySQLConfig) (*MySQL, error) {
+	if config.MaxOpenConns == 0 {
+		config.MaxOpenConns = mysqlDefaultMaxOpenConns
 	}
 	if config.Timeout == 0 {
 		config.Timeout = mysqlDefaultTimeout
 	}
+	if config.QueryTimeout == 0 {
+		config.QueryTimeout = mysqlDefaultQueryTimeout
+	}
 	db, err := sqlx.Connect("mysql", config.FormatDSN())
 	if err != nil {
-		panic(err)
+		return nil, classifyMySQLError(err)
+	}
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	if config.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	return &MySQL{DB: db, queryTimeout: config.QueryTimeout}, nil
+}
+
+type txContextKey struct{}
+
+// txFromContext returns the transaction WithinTx put in ctx, if any.
+func txFromContext(ctx context.Context) (*mySQLTx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*mySQLTx)
+	return tx, ok
+}
+
+func (m *MySQL) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.GetContext(ctx, dest, query, args...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.queryTimeout)
+	defer cancel()
+	return classifyMySQLError(m.DB.GetContext(ctx, dest, query, args...))
+}
+
+func (m *MySQL) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.SelectContext(ctx, dest, query, args...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.queryTimeout)
+	defer cancel()
+	return classifyMySQLError(m.DB.SelectContext(ctx, dest, query, args...))
+}
+
+func (m *MySQL) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.ExecContext(ctx, query, args...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.queryTimeout)
+	defer cancel()
+	res, err := m.DB.ExecContext(ctx, query, args...)
+	return res, classifyMySQLError(err)
+}
+
+func (m *MySQL) WithinTx(ctx context.Context, fn TxFunc, opts ...TxOption) (err error) {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(ctx, tx)
+	}
+
+	var txOptions sql.TxOptions
+	for _, opt := range opts {
+		opt(&txOptions)
+	}
+	sqlxTx, err := m.DB.BeginTxx(ctx, &txOptions)
+	if err != nil {
+		return classifyMySQLError(err)
+	}
+	tx := &mySQLTx{tx: sqlxTx, queryTimeout: m.queryTimeout}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.tx.Rollback()
+			err = fmt.Errorf("%w: %v", ErrTxPanic, r)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txContextKey{}, tx), tx); err != nil {
+		_ = tx.tx.Rollback()
+		return err
+	}
+	return classifyMySQLError(tx.tx.Commit())
+}
+
+type mySQLTx struct {
+	tx           *sqlx.Tx
+	queryTimeout time.Duration
+}
+
+func (t *mySQLTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+	return classifyMySQLError(t.tx.GetContext(ctx, dest, query, args...))
+}
+
+func (t *mySQLTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+	return classifyMySQLError(t.tx.SelectContext(ctx, dest, query, args...))
+}
+
+func (t *mySQLTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+	res, err := t.tx.ExecContext(ctx, query, args...)
+	return res, classifyMySQLError(err)
+}
+
+// classifyMySQLError gives err the kind of failure it is. The driver error
+// stays in the chain, so errors.As still finds a *mysql.MySQLError.
+func classifyMySQLError(err error) error {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var mysqlError *mysql.MySQLError
+	if errors.As(err, &mysqlError) && mysqlError.Number == MySQLDuplicateEntry {
+		return apperror.Wrap(apperror.KindConflict, "mysql", err)
+	}
+
+	var netError net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netError) {
+		return apperror.Wrap(apperror.KindUnavailable, "mysql", err)
 	}
-	return db
+	return apperror.Wrap(apperror.KindInternal, "mysql", err)
 }
@@ -0,0 +1,26 @@
+package datasource
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"store-management/internal/apperror"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyMySQLError(t *testing.T) {
+	assert.Nil(t, classifyMySQLError(nil))
+	assert.Equal(t, sql.ErrNoRows, classifyMySQLError(sql.ErrNoRows))
+
+	duplicate := classifyMySQLError(&mysql.MySQLError{Number: MySQLDuplicateEntry})
+	assert.Equal(t, apperror.KindConflict, apperror.KindOf(duplicate))
+	var mysqlError *mysql.MySQLError
+	assert.True(t, errors.As(duplicate, &mysqlError))
+
+	assert.Equal(t, apperror.KindUnavailable, apperror.KindOf(classifyMySQLError(driver.ErrBadConn)))
+	assert.Equal(t, apperror.KindUnavailable, apperror.KindOf(classifyMySQLError(mysql.ErrInvalidConn)))
+	assert.Equal(t, apperror.KindInternal, apperror.KindOf(classifyMySQLError(&mysql.MySQLError{Number: 1064})))
+}
@@ -1,10 +1,9 @@
 package datasource
 
 import (
+	"context"
 	"database/sql"
-	"errors"
-
-	"github.com/jmoiron/sqlx"
+	"store-management/internal/apperror"
 )
 
 type SQL interface {
@@ -14,26 +13,52 @@ type SQL interface {
 }
 
 type Queryer interface {
-	Get(dest interface{}, query string, args ...interface{}) error
-	Select(dest interface{}, query string, args ...interface{}) error
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 }
 
 type Execer interface {
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 type TxExecer interface {
 	Execer
 	Queryer
-	Rollback() error
-	Commit() error
 }
 
+// TxFunc is the body of a transaction. Statements run on tx, or on any SQL
+// given the ctx passed to the TxFunc, are part of the transaction, so a
+// service can compose several repositories in one unit of work.
+type TxFunc func(ctx context.Context, tx TxExecer) error
+
 type Transaction interface {
-	MustBegin() *sqlx.Tx
+	// WithinTx runs fn in a transaction, committing it when fn returns nil
+	// and rolling it back when fn returns an error or panics. Called inside
+	// another WithinTx, fn joins the outer transaction and opts are ignored.
+	WithinTx(ctx context.Context, fn TxFunc, opts ...TxOption) error
+}
+
+type TxOption func(*sql.TxOptions)
+
+// WithIsolation runs the transaction at level instead of the database
+// default.
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(opts *sql.TxOptions) {
+		opts.Isolation = level
+	}
+}
+
+// ReadOnly marks the transaction read only.
+func ReadOnly() TxOption {
+	return func(opts *sql.TxOptions) {
+		opts.ReadOnly = true
+	}
 }
 
+// ErrTxPanic is returned by WithinTx when the TxFunc panicked.
+var ErrTxPanic = apperror.New(apperror.KindInternal, "transaction panicked")
+
 var (
-	ErrDuplicateEntry = errors.New("duplicate entry")
-	ErrNoRows         = errors.New("no rows found")
+	ErrDuplicateEntry = apperror.New(apperror.KindConflict, "duplicate entry")
+	ErrNoRows         = apperror.New(apperror.KindNotFound, "no rows found")
 )
@@ -0,0 +1,16 @@
+package datasource
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxOptions(t *testing.T) {
+	var opts sql.TxOptions
+	for _, opt := range []TxOption{WithIsolation(sql.LevelSerializable), ReadOnly()} {
+		opt(&opts)
+	}
+	assert.Equal(t, sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, opts)
+}
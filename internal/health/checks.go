@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"store-management/internal/datasource"
+	"store-management/internal/migrate"
+	"strings"
+	"time"
+)
+
+// Pinger is a connection pool such as datasource.MySQL.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck checks that the pool can reach its database.
+func PingCheck(db Pinger) CheckFunc {
+	return db.PingContext
+}
+
+// CacheCheck checks that a value written to the cache can be read back.
+func CacheCheck(cache datasource.Cache) CheckFunc {
+	return func(ctx context.Context) error {
+		probe := time.Now().UnixNano()
+		// Every probe has its own key so concurrent checks do not race.
+		key := fmt.Sprintf("health:probe:%d", probe)
+		if err := cache.Set(key, probe, time.Minute); err != nil {
+			return err
+		}
+		defer cache.Invalidate(key)
+		value, err := cache.Get(key)
+		if err != nil {
+			return err
+		}
+		if value != probe {
+			return fmt.Errorf("cache did not return the value just written")
+		}
+		return nil
+	}
+}
+
+// MigrationCheck fails while the database misses some of the migrations
+// built into the server.
+func MigrationCheck(migrator *migrate.Migrator) CheckFunc {
+	return func(ctx context.Context) error {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations: %s", len(pending), strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,30 @@
+package health
+
+import (
+	"net/http"
+	"store-management/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LivenessHandler answers as long as the process can serve requests. It
+// checks no dependency, so an outage of one does not get the server
+// restarted.
+func LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.JSON(c, http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+	}
+}
+
+// Handler answers with the report of every check, with 503 when the server
+// is not ready.
+func (r *Readiness) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Check(c.Request.Context())
+		if report.Status != StatusUp {
+			response.JSON(c, http.StatusServiceUnavailable, response.New(http.StatusServiceUnavailable, response.MessageUnavailable, report))
+			return
+		}
+		response.JSON(c, http.StatusOK, response.New(http.StatusOK, response.MessageOK, report))
+	}
+}
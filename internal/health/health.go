@@ -0,0 +1,137 @@
+// Package health reports whether the server is alive and whether it should
+// receive traffic.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// ErrDraining is reported once shutdown has started.
+var ErrDraining = errors.New("server is shutting down")
+
+// CheckFunc tells whether a dependency can be used. It must give up when ctx
+// is done.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	timeout time.Duration
+	fn      CheckFunc
+}
+
+// Readiness tells load balancers whether to route requests to this server,
+// by running a check on every dependency. It turns to failing for good once
+// shutdown starts draining connections.
+type Readiness struct {
+	draining atomic.Bool
+	checks   []check
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Add registers a dependency check, cut off after timeout. Checks must be
+// added before the server starts.
+func (r *Readiness) Add(name string, timeout time.Duration, fn CheckFunc) {
+	r.checks = append(r.checks, check{name: name, timeout: timeout, fn: fn})
+}
+
+// Drain makes the server report itself as not ready.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// ComponentReport is the result of one check.
+type ComponentReport struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the result of every check. The server is ready when Status is
+// StatusUp.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// Check runs every check concurrently, each within its own timeout.
+func (r *Readiness) Check(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentReport, len(r.checks)+1)}
+	if r.Draining() {
+		report.Status = StatusDown
+		report.Components["server"] = ComponentReport{Status: StatusDown, Error: ErrDraining.Error(), Duration: "0s"}
+		return report
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range r.checks {
+		c := c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component := run(ctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[c.name] = component
+			if component.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// run runs one check, giving up at its timeout even if the check ignores
+// ctx.
+func run(ctx context.Context, c check) ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		result <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	component := ComponentReport{Status: StatusUp, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	return component
+}
@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"store-management/internal/datasource"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness_Check(t *testing.T) {
+	readiness := NewReadiness()
+	readiness.Add("cache", time.Second, CacheCheck(datasource.NewInMemoryCache()))
+	readiness.Add("writer", time.Second, func(ctx context.Context) error { return nil })
+
+	report := readiness.Check(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, StatusUp, report.Components["cache"].Status)
+	assert.Equal(t, StatusUp, report.Components["writer"].Status)
+
+	readiness.Add("reader", time.Second, func(ctx context.Context) error { return errors.New("connection refused") })
+	report = readiness.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusUp, report.Components["writer"].Status)
+	assert.Equal(t, "connection refused", report.Components["reader"].Error)
+}
+
+func TestReadiness_CheckTimesOut(t *testing.T) {
+	readiness := NewReadiness()
+	hang := make(chan struct{})
+	defer close(hang)
+	readiness.Add("stuck", 10*time.Millisecond, func(ctx context.Context) error {
+		<-hang
+		return nil
+	})
+	readiness.Add("panics", time.Second, func(ctx context.Context) error { panic("boom") })
+
+	report := readiness.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "timed out after 10ms", report.Components["stuck"].Error)
+	assert.Equal(t, "check panicked: boom", report.Components["panics"].Error)
+}
+
+func TestReadiness_Handler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := NewReadiness()
+	readiness.Add("writer", time.Second, func(ctx context.Context) error { return nil })
+	r := gin.New()
+	r.GET("/livez", LivenessHandler())
+	r.GET("/readyz", readiness.Handler())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/readyz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Data Report `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, StatusUp, body.Data.Components["writer"].Status)
+
+	readiness.Drain()
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz").Code)
+	assert.Equal(t, http.StatusOK, get("/livez").Code)
+}
@@ -0,0 +1,134 @@
+// Package logging writes structured JSON logs. Records logged with a request
+// context carry the request ID, principal, store and route of that request,
+// however deep in the services and repositories they are logged.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"store-management/internal/apperror"
+	"strings"
+	"sync"
+)
+
+// Fields describe the request a context belongs to. They are filled in as the
+// request goes through the middlewares and services, so they are shared by
+// pointer and guarded by a mutex.
+type Fields struct {
+	mu        sync.Mutex
+	requestID string
+	route     string
+	userID    int64
+	apiKeyID  int64
+	storeID   int64
+}
+
+type fieldsKey struct{}
+
+// NewContext returns a context carrying new request fields.
+func NewContext(ctx context.Context, requestID, route string) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &Fields{requestID: requestID, route: route})
+}
+
+func fieldsFrom(ctx context.Context) *Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(*Fields)
+	return fields
+}
+
+func update(ctx context.Context, fn func(f *Fields)) {
+	if fields := fieldsFrom(ctx); fields != nil {
+		fields.mu.Lock()
+		defer fields.mu.Unlock()
+		fn(fields)
+	}
+}
+
+// RequestID returns the ID of the request ctx belongs to, or an empty string.
+func RequestID(ctx context.Context) string {
+	fields := fieldsFrom(ctx)
+	if fields == nil {
+		return ""
+	}
+	fields.mu.Lock()
+	defer fields.mu.Unlock()
+	return fields.requestID
+}
+
+func SetUserID(ctx context.Context, userID int64) {
+	update(ctx, func(f *Fields) { f.userID = userID })
+}
+
+func SetAPIKeyID(ctx context.Context, apiKeyID int64) {
+	update(ctx, func(f *Fields) { f.apiKeyID = apiKeyID })
+}
+
+func SetStoreID(ctx context.Context, storeID int64) {
+	update(ctx, func(f *Fields) { f.storeID = storeID })
+}
+
+func (f *Fields) attrs() []slog.Attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	attrs := []slog.Attr{slog.String("request_id", f.requestID)}
+	if f.route != "" {
+		attrs = append(attrs, slog.String("route", f.route))
+	}
+	if f.userID != 0 {
+		attrs = append(attrs, slog.Int64("user_id", f.userID))
+	}
+	if f.apiKeyID != 0 {
+		attrs = append(attrs, slog.Int64("api_key_id", f.apiKeyID))
+	}
+	if f.storeID != 0 {
+		attrs = append(attrs, slog.Int64("store_id", f.storeID))
+	}
+	return attrs
+}
+
+// Error describes err along with its apperror.Kind.
+func Error(err error) slog.Attr {
+	return slog.Group("error",
+		slog.String("message", err.Error()),
+		slog.String("kind", apperror.KindOf(err).String()),
+	)
+}
+
+// handler adds the request fields of the context to every record.
+type handler struct {
+	slog.Handler
+}
+
+func (h handler) Handle(ctx context.Context, record slog.Record) error {
+	if fields := fieldsFrom(ctx); fields != nil {
+		record.AddAttrs(fields.attrs()...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{h.Handler.WithGroup(name)}
+}
+
+// New returns a logger writing JSON records of at least level to w.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(handler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// ParseLevel parses debug, info, warn or error; empty means info.
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if strings.TrimSpace(value) == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(strings.TrimSpace(value)))
+	return level, err
+}
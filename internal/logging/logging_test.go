@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"store-management/internal/apperror"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_AddsRequestFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	ctx := NewContext(context.Background(), "req-1", "/v1/product/:id")
+	SetAPIKeyID(ctx, 5)
+	SetStoreID(ctx, 9)
+	logger.With("component", "test").ErrorContext(ctx, "failed", Error(apperror.Wrap(apperror.KindUnavailable, "mysql", errors.New("down"))))
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "/v1/product/:id", record["route"])
+	assert.Equal(t, 5.0, record["api_key_id"])
+	assert.Equal(t, 9.0, record["store_id"])
+	assert.Equal(t, "test", record["component"])
+	assert.Nil(t, record["user_id"])
+	assert.Equal(t, "unavailable", record["error"].(map[string]interface{})["kind"])
+	assert.Equal(t, "req-1", RequestID(ctx))
+}
+
+func TestHandler_WithoutRequest(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, slog.LevelWarn).Info("dropped")
+	assert.Empty(t, buf.String())
+
+	SetUserID(context.Background(), 1)
+	New(&buf, slog.LevelInfo).InfoContext(context.Background(), "kept")
+	assert.NotContains(t, buf.String(), "request_id")
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, level)
+
+	level, err = ParseLevel("debug")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	_, err = ParseLevel("loud")
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+// Package metrics exposes the server metrics in the Prometheus text format.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"store-management/internal/datasource"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests matching no route, so scanners probing
+// random paths cannot blow up the number of series.
+const unmatchedRoute = "unmatched"
+
+// Registry holds every metric of the server.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by route template and status.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// ProductsCreated counts products added to any store.
+	ProductsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "products_created_total",
+		Help: "Products created.",
+	})
+	// ProductSearches counts product searches run against the repository.
+	ProductSearches = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "product_searches_total",
+		Help: "Product searches executed.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		ProductsCreated,
+		ProductSearches,
+	)
+}
+
+// Handler serves the metrics of Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware records every request under its route template, such as
+// /v1/product/:id, rather than its path.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		labels := prometheus.Labels{
+			"method": c.Request.Method,
+			"route":  route,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+		httpRequests.With(labels).Inc()
+		httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDB exposes the sql.DBStats of a connection pool, labeled with
+// db_name set to pool.
+func RegisterDB(pool string, db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, pool))
+}
+
+// RegisterCache exposes the hits, misses and evictions of cache.
+func RegisterCache(cache datasource.Cache) {
+	Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Cache lookups that found a live item.",
+		}, func() float64 { return float64(cache.Stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Cache lookups that found nothing or an expired item.",
+		}, func() float64 { return float64(cache.Stats().Misses) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Cache items dropped because they expired.",
+		}, func() float64 { return float64(cache.Stats().Evictions) }),
+	)
+}
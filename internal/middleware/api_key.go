@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"store-management/internal/logging"
+	"store-management/internal/response"
+	"store-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIKeyHeader     = "X-API-Key"
+	APIKeyContextKey = "api_key"
+)
+
+// APIKeyMiddleware authenticates machine clients that present an X-API-Key
+// header. It must run before JwtMiddleware, which skips requests that were
+// already authenticated by an API key.
+func APIKeyMiddleware(apiKeyService service.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(APIKeyHeader)
+		if rawKey == "" {
+			c.Next()
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				response.AbortJSON(c, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+				return
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Set(APIKeyContextKey, key)
+		c.Set(AuthMethodKey, AuthMethodAPIKey)
+		logging.SetAPIKeyID(c.Request.Context(), key.ID)
+		logging.SetStoreID(c.Request.Context(), key.StoreID)
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import "store-management/internal/token"
+
+type AuthMethod string
+
+const (
+	AuthMethodKey = "auth_method"
+
+	AuthMethodCookie AuthMethod = "cookie"
+	AuthMethodBearer AuthMethod = "bearer"
+	AuthMethodAPIKey AuthMethod = "api_key"
+)
+
+func authMethodFromSource(source token.Source) AuthMethod {
+	if source == token.SourceBearer {
+		return AuthMethodBearer
+	}
+	return AuthMethodCookie
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"store-management/internal/cookie"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCSRFRouter(method AuthMethod, exemptPaths ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if method != "" {
+			c.Set(AuthMethodKey, method)
+		}
+	})
+	r.Use(CSRFMiddleware(exemptPaths...))
+	r.GET("/resource", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.POST("/resource", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func serveCSRF(r *gin.Engine, method, csrfCookie, csrfHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/resource", nil)
+	if csrfCookie != "" {
+		req.AddCookie(&http.Cookie{Name: cookie.CSRFName, Value: csrfCookie})
+	}
+	if csrfHeader != "" {
+		req.Header.Set(CSRFHeaderName, csrfHeader)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCSRFMiddleware_Cookie(t *testing.T) {
+	r := newCSRFRouter(AuthMethodCookie)
+
+	assert.Equal(t, http.StatusOK, serveCSRF(r, http.MethodPost, "csrf", "csrf").Code)
+	assert.Equal(t, http.StatusOK, serveCSRF(r, http.MethodGet, "csrf", "").Code)
+	assert.Equal(t, http.StatusForbidden, serveCSRF(r, http.MethodPost, "csrf", "").Code)
+	assert.Equal(t, http.StatusForbidden, serveCSRF(r, http.MethodPost, "csrf", "other").Code)
+	assert.Equal(t, http.StatusForbidden, serveCSRF(r, http.MethodPost, "", "csrf").Code)
+}
+
+func TestCSRFMiddleware_MissingCookie_IssuesToken(t *testing.T) {
+	r := newCSRFRouter(AuthMethodCookie)
+
+	rec := serveCSRF(r, http.MethodGet, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	cookies := rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, cookie.CSRFName, cookies[0].Name)
+		assert.NotEmpty(t, cookies[0].Value)
+		assert.False(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.StatusOK, serveCSRF(r, http.MethodPost, cookies[0].Value, cookies[0].Value).Code)
+	}
+}
+
+func TestCSRFMiddleware_NotCookie_Skipped(t *testing.T) {
+	for _, method := range []AuthMethod{AuthMethodBearer, AuthMethodAPIKey, ""} {
+		r := newCSRFRouter(method)
+
+		rec := serveCSRF(r, http.MethodPost, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, method)
+		assert.Empty(t, rec.Result().Cookies(), method)
+	}
+}
+
+func TestCSRFMiddleware_ExemptPath_Skipped(t *testing.T) {
+	r := newCSRFRouter(AuthMethodCookie, "/resource")
+
+	assert.Equal(t, http.StatusOK, serveCSRF(r, http.MethodPost, "", "").Code)
+	assert.Equal(t, http.StatusOK, serveCSRF(r, http.MethodPost, "csrf", "other").Code)
+}
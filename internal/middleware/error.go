@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"store-management/internal/apperror"
+	"store-management/internal/logging"
+	"store-management/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errorStatuses = map[apperror.Kind]int{
+	apperror.KindInternal:        http.StatusInternalServerError,
+	apperror.KindValidation:      http.StatusBadRequest,
+	apperror.KindUnauthorized:    http.StatusUnauthorized,
+	apperror.KindForbidden:       http.StatusForbidden,
+	apperror.KindNotFound:        http.StatusNotFound,
+	apperror.KindConflict:        http.StatusConflict,
+	apperror.KindTooManyRequests: http.StatusTooManyRequests,
+	apperror.KindUnavailable:     http.StatusServiceUnavailable,
+}
+
+var defaultErrorMessages = map[int]string{
+	http.StatusInternalServerError: response.MessageInternalError,
+	http.StatusBadRequest:          response.MessageInvalidInput,
+	http.StatusUnauthorized:        response.MessageUnauthorized,
+	http.StatusForbidden:           response.MessageForbidden,
+	http.StatusNotFound:            response.MessageNotFound,
+	http.StatusConflict:            response.MessageConflict,
+	http.StatusTooManyRequests:     response.MessageTooManyRequests,
+	http.StatusServiceUnavailable:  response.MessageUnavailable,
+}
+
+// ErrorStatus is the HTTP status errors of kind are reported with.
+func ErrorStatus(kind apperror.Kind) int {
+	if status, ok := errorStatuses[kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorMiddleware writes the response for the last error a handler attached
+// with c.Error, with a status decided by its apperror.Kind, and logs every
+// attached error. It also recovers from panics, which are logged with their
+// stack and answered with a 500. It must come before every middleware but
+// RequestIDMiddleware and LoggerMiddleware so that it sees the errors and
+// panics of all the others.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				slog.ErrorContext(c.Request.Context(), "panic serving request",
+					"method", c.Request.Method,
+					"path", c.Request.URL.Path,
+					"panic", fmt.Sprint(recovered),
+					"stack", string(debug.Stack()),
+				)
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				response.AbortJSON(c, http.StatusInternalServerError, response.New(http.StatusInternalServerError, response.MessageInternalError, nil))
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := c.Errors.Last().Err
+		status := ErrorStatus(apperror.KindOf(err))
+		for _, e := range c.Errors {
+			slog.ErrorContext(c.Request.Context(), "error serving request",
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"status", status,
+				logging.Error(e.Err),
+			)
+		}
+		if c.Writer.Written() {
+			return
+		}
+
+		message := apperror.Message(err)
+		if message == "" {
+			message = defaultErrorMessages[status]
+		}
+		response.AbortJSON(c, status, response.New(status, message, nil))
+	}
+}
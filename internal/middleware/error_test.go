@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"store-management/internal/apperror"
+	"store-management/internal/response"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveError(handler gin.HandlerFunc) (int, response.Response) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.GET("/", handler)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body response.Response
+	if rec.Body.Len() > 0 {
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	}
+	return rec.Code, body
+}
+
+func TestErrorMiddleware_Kinds(t *testing.T) {
+	notFound := apperror.New(apperror.KindNotFound, "store not found")
+
+	code, body := serveError(func(c *gin.Context) {
+		c.Error(apperror.Wrap(apperror.KindInternal, "store.Get", notFound))
+	})
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, http.StatusNotFound, body.Meta.Code)
+	assert.Equal(t, "store not found", body.Meta.Message)
+
+	code, body = serveError(func(c *gin.Context) {
+		c.Error(apperror.Wrap(apperror.KindConflict, "mysql", errors.New("Duplicate entry '1' for key 'PRIMARY'")))
+	})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, response.MessageConflict, body.Meta.Message)
+
+	code, body = serveError(func(c *gin.Context) {
+		c.Error(apperror.Wrap(apperror.KindUnavailable, "mysql", errors.New("dial tcp: connection refused")))
+	})
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, response.MessageUnavailable, body.Meta.Message)
+}
+
+func TestErrorMiddleware_InternalErrorHidden(t *testing.T) {
+	code, body := serveError(func(c *gin.Context) {
+		c.Error(errors.New("secret details"))
+	})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, response.MessageInternalError, body.Meta.Message)
+}
+
+func TestErrorMiddleware_WrittenResponseKept(t *testing.T) {
+	code, body := serveError(func(c *gin.Context) {
+		c.JSON(http.StatusOK, response.New(http.StatusOK, response.MessageOK, nil))
+		c.Error(errors.New("after the response"))
+	})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, response.MessageOK, body.Meta.Message)
+}
+
+func TestErrorMiddleware_Panic(t *testing.T) {
+	var code int
+	var body response.Response
+	require.NotPanics(t, func() {
+		code, body = serveError(func(c *gin.Context) {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, response.MessageInternalError, body.Meta.Message)
+}
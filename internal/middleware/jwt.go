@@ -1,47 +1,83 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
+	"store-management/internal/logging"
 	"store-management/internal/repository"
 	"store-management/internal/response"
-	"strconv"
-	"time"
+	"store-management/internal/service"
+	"store-management/internal/token"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func JwtMiddleware(userRepository repository.UserRepository) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		tokenString, err := c.Cookie("auth_token")
+// SessionIDKey holds the session (refresh token family) of the access token.
+const SessionIDKey = "session_id"
 
-		if err != nil {
+// JwtMiddleware authenticates the request with the access token from either
+// the Authorization header or the auth cookie, the header winning when both
+// are present. A bearer token is an explicit credential, so an invalid one is
+// rejected with 401 instead of falling back to the cookie. An invalid cookie is
+// ignored and the request continues anonymously, so a stale cookie never
+// blocks logging in again. Revoked tokens, and tokens issued before the user's
+// password last changed or whose session was revoked, are rejected regardless
+// of source.
+func JwtMiddleware(userRepository repository.UserRepository, sessionService service.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(APIKeyContextKey); ok {
 			c.Next()
 			return
 		}
 
-		token, _ := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-			}
-
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		tokenString, source := token.FromRequest(c.Request)
+		if source == token.SourceNone {
+			c.Next()
+			return
+		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			if float64(time.Now().Unix()) > claims["exp"].(float64) {
-				c.JSON(http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+		claims, err := token.Parse(tokenString)
+		if err != nil {
+			if source == token.SourceBearer {
+				response.AbortJSON(c, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
 				return
 			}
+			c.Next()
+			return
+		}
 
-			if sub, err := claims.GetSubject(); err == nil {
-				if id, err := strconv.ParseInt(sub, 10, 64); err == nil {
-					if user, err := userRepository.FindUserByID(id); err == nil {
-						c.Set("user", user)
+		blocked, err := userRepository.IsAuthTokenBlocked(c.Request.Context(), claims.ID)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if blocked {
+			response.AbortJSON(c, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+			return
+		}
+
+		if id, err := claims.UserID(); err == nil {
+			if user, err := userRepository.FindUserByID(c.Request.Context(), id); err == nil {
+				if user.TokensValidAfter != nil && (claims.IssuedAt == nil || claims.IssuedAt.Time.Before(*user.TokensValidAfter)) {
+					response.AbortJSON(c, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+					return
+				}
+				if claims.SessionID != "" {
+					if err := sessionService.CheckSession(c.Request.Context(), user.ID, claims.SessionID, c.ClientIP()); err != nil {
+						if errors.Is(err, service.ErrSessionRevoked) {
+							response.AbortJSON(c, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+							return
+						}
+						c.Error(err)
+						c.Abort()
+						return
 					}
 				}
+				c.Set("user", user)
+				c.Set(SessionIDKey, claims.SessionID)
+				logging.SetUserID(c.Request.Context(), user.ID)
+				c.Set(AuthMethodKey, authMethodFromSource(source))
 			}
 		}
 		c.Next()
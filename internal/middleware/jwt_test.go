@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"store-management/internal/model"
+	"store-management/internal/service"
+	"store-management/internal/token"
+	mock2 "store-management/mock"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type JwtMiddlewareSuite struct {
+	suite.Suite
+	mockedUserRepository    *mock2.UserRepositoryMock
+	mockedSessionRepository *mock2.SessionRepositoryMock
+	router                  *gin.Engine
+}
+
+func (s *JwtMiddlewareSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	keys, err := token.NewHMACKeySet([]byte("test-secret-of-at-least-32-bytes"))
+	s.Require().NoError(err)
+	token.SetKeySet(keys)
+
+	s.mockedUserRepository = &mock2.UserRepositoryMock{}
+	s.mockedSessionRepository = &mock2.SessionRepositoryMock{}
+	mockRepo := mock2.NewMockedRepository(s.mockedUserRepository, &mock2.StoreRepositoryMock{}, s.mockedSessionRepository)
+	s.router = gin.New()
+	s.router.Use(JwtMiddleware(s.mockedUserRepository, service.NewSessionService(mockRepo)))
+	s.router.GET("/me", func(c *gin.Context) {
+		user, ok := c.Get("user")
+		if !ok {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.String(http.StatusOK, "%d:%s", user.(*model.User).ID, c.MustGet(AuthMethodKey).(AuthMethod))
+	})
+}
+
+func (s *JwtMiddlewareSuite) requestWith(authorization, cookie string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: token.CookieName, Value: cookie})
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *JwtMiddlewareSuite) request(cookie string) *httptest.ResponseRecorder {
+	return s.requestWith("", cookie)
+}
+
+func (s *JwtMiddlewareSuite) issue(userId int64) (string, *token.Claims) {
+	tokenString, claims, err := token.Issue(userId, "")
+	s.Require().NoError(err)
+	return tokenString, claims
+}
+
+func (s *JwtMiddlewareSuite) TestValidToken_Success() {
+	tokenString, claims, err := token.Issue(1, "")
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1}, nil).Once()
+
+	rec := s.request(tokenString)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("1:cookie", rec.Body.String())
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestBearerToken_Success() {
+	tokenString, claims := s.issue(1)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1}, nil).Once()
+
+	rec := s.requestWith("Bearer "+tokenString, "")
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("1:bearer", rec.Body.String())
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestBearerAndCookie_BearerWins() {
+	bearerToken, bearerClaims := s.issue(1)
+	cookieToken, _ := s.issue(2)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", bearerClaims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1}, nil).Once()
+
+	rec := s.requestWith("bearer "+bearerToken, cookieToken)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("1:bearer", rec.Body.String())
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "FindUserByID", int64(2))
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestInvalidBearerWithValidCookie_Unauthorized() {
+	cookieToken, _ := s.issue(2)
+
+	rec := s.requestWith("Bearer not-a-jwt", cookieToken)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "IsAuthTokenBlocked", mock.Anything)
+}
+
+func (s *JwtMiddlewareSuite) TestRevokedBearerToken_Unauthorized() {
+	tokenString, claims := s.issue(1)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(true, nil).Once()
+
+	rec := s.requestWith("Bearer "+tokenString, "")
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestOtherAuthorizationScheme_FallsBackToCookie() {
+	cookieToken, claims := s.issue(2)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(2)).Return(&model.User{ID: 2}, nil).Once()
+
+	rec := s.requestWith("Basic dXNlcjpwYXNz", cookieToken)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("2:cookie", rec.Body.String())
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestRevokedToken_Unauthorized() {
+	tokenString, claims, err := token.Issue(1, "")
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(true, nil).Once()
+
+	rec := s.request(tokenString)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "FindUserByID", int64(1))
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestTokenIssuedBeforePasswordChange_Unauthorized() {
+	tokenString, claims := s.issue(1)
+	validAfter := claims.IssuedAt.Time.Add(time.Second)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1, TokensValidAfter: &validAfter}, nil).Once()
+
+	rec := s.request(tokenString)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestTokenIssuedAfterPasswordChange_Success() {
+	tokenString, claims := s.issue(1)
+	validAfter := claims.IssuedAt.Time
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1, TokensValidAfter: &validAfter}, nil).Once()
+
+	rec := s.request(tokenString)
+	s.Equal(http.StatusOK, rec.Code)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestLiveSession_Success() {
+	tokenString, claims, err := token.Issue(1, "family-id")
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1}, nil).Once()
+	s.mockedSessionRepository.On("FindDeviceSession", "family-id").Return(&model.DeviceSession{UserID: 1, FamilyID: "family-id", IP: "192.0.2.1", LastSeenAt: time.Now().Add(-time.Hour)}, nil).Once()
+	s.mockedSessionRepository.On("TouchDeviceSession", "family-id", mock.AnythingOfType("time.Time"), "192.0.2.1").Return(nil).Once()
+
+	rec := s.request(tokenString)
+	s.Equal(http.StatusOK, rec.Code)
+
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestRecentlySeenSession_NotTouched() {
+	tokenString, claims, err := token.Issue(1, "family-id")
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1}, nil).Once()
+	s.mockedSessionRepository.On("FindDeviceSession", "family-id").Return(&model.DeviceSession{UserID: 1, FamilyID: "family-id", IP: "192.0.2.1", LastSeenAt: time.Now()}, nil).Once()
+
+	rec := s.request(tokenString)
+	s.Equal(http.StatusOK, rec.Code)
+
+	s.mockedSessionRepository.AssertNotCalled(s.T(), "TouchDeviceSession", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *JwtMiddlewareSuite) TestRevokedSession_Unauthorized() {
+	tokenString, claims, err := token.Issue(1, "family-id")
+	s.Require().NoError(err)
+	revokedAt := time.Now()
+
+	s.mockedUserRepository.On("IsAuthTokenBlocked", claims.ID).Return(false, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(&model.User{ID: 1}, nil).Once()
+	s.mockedSessionRepository.On("FindDeviceSession", "family-id").Return(&model.DeviceSession{UserID: 1, FamilyID: "family-id", RevokedAt: &revokedAt}, nil).Once()
+
+	rec := s.requestWith("Bearer "+tokenString, "")
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *JwtMiddlewareSuite) TestInvalidToken_Anonymous() {
+	rec := s.request("not-a-jwt")
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "IsAuthTokenBlocked", mock.Anything)
+}
+
+func TestJwtMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(JwtMiddlewareSuite))
+}
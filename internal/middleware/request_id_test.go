@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"store-management/internal/apperror"
+	"store-management/internal/logging"
+	"store-management/internal/response"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveWithRequestID(requestID string) (*httptest.ResponseRecorder, response.Response) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), ErrorMiddleware())
+	r.GET("/v1/product/:id", func(c *gin.Context) {
+		c.Error(apperror.New(apperror.KindNotFound, "product not found"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/product/1", nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var body response.Response
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec, body
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	rec, body := serveWithRequestID("client-id-1")
+	assert.Equal(t, "client-id-1", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, "client-id-1", body.Meta.RequestID)
+
+	for _, invalid := range []string{"", "has space", strings.Repeat("a", maxRequestIDLength+1)} {
+		rec, body = serveWithRequestID(invalid)
+		generated := rec.Header().Get(RequestIDHeader)
+		assert.Len(t, generated, 32, invalid)
+		assert.Equal(t, generated, body.Meta.RequestID, invalid)
+	}
+}
+
+func TestLoggerMiddleware_LogsRequestFields(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logging.New(&buf, slog.LevelInfo))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), LoggerMiddleware(), ErrorMiddleware())
+	r.GET("/v1/store/:storeId", func(c *gin.Context) {
+		logging.SetUserID(c.Request.Context(), 7)
+		c.Error(apperror.New(apperror.KindConflict, "conflict"))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/v1/store/3", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "request handled", record["msg"])
+	assert.Equal(t, "abc", record["request_id"])
+	assert.Equal(t, "/v1/store/:storeId", record["route"])
+	assert.Equal(t, 7.0, record["user_id"])
+	assert.Equal(t, 409.0, record["status"])
+	assert.Equal(t, "conflict", record["error_kind"])
+}
@@ -0,0 +1,200 @@
+// Package migrate applies the goose SQL migrations to MySQL with goose,
+// holding a MySQL advisory lock so replicas starting at the same time apply
+// every migration exactly once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+)
+
+const (
+	lockName           = "store-management:migrate"
+	defaultLockTimeout = 5 * time.Minute
+)
+
+// ErrLockTimeout is returned when another process held the migration lock
+// for longer than the lock timeout.
+var ErrLockTimeout = errors.New("timed out waiting for the migration lock")
+
+// ErrNoMigration is returned by Down and Redo when no migration is applied.
+var ErrNoMigration = errors.New("no migration is applied")
+
+// Status is the state of one migration.
+type Status struct {
+	Name string
+	// AppliedAt is nil for a pending migration.
+	AppliedAt *time.Time
+}
+
+// Migrator runs the goose migrations of a file system while holding the
+// migration lock.
+type Migrator struct {
+	db          *sql.DB
+	store       database.Store
+	provider    *goose.Provider
+	LockTimeout time.Duration
+	// Logf reports every applied or rolled back migration when set.
+	Logf func(format string, args ...interface{})
+}
+
+// New returns a migrator for the goose migrations in the root of fsys.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	return newMigrator(db, fsys, goose.DefaultTablename)
+}
+
+// newMigrator returns a migrator that records applied versions in
+// versionTable.
+func newMigrator(db *sql.DB, fsys fs.FS, versionTable string) (*Migrator, error) {
+	store, err := database.NewStore(database.DialectMySQL, versionTable)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := goose.NewProvider("", db, fsys, goose.WithStore(store))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, store: store, provider: provider, LockTimeout: defaultLockTimeout}, nil
+}
+
+// Up applies every pending migration in version order.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		results, err := m.provider.Up(ctx)
+		var partial *goose.PartialError
+		if errors.As(err, &partial) {
+			results = partial.Applied
+		}
+		m.log(results...)
+		return err
+	})
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		_, err := m.down(ctx)
+		return err
+	})
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		result, err := m.down(ctx)
+		if err != nil {
+			return err
+		}
+		result, err = m.provider.ApplyVersion(ctx, result.Source.Version, true)
+		if err != nil {
+			return err
+		}
+		m.log(result)
+		return nil
+	})
+}
+
+// Status reports every known migration and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func() error {
+		migrations, err := m.provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			status := Status{Name: filepath.Base(migration.Source.Path)}
+			if migration.State == goose.StateApplied {
+				appliedAt := migration.AppliedAt
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Pending returns the names of the migrations not applied yet. Unlike Status
+// it neither takes the migration lock nor creates the version table, so it
+// is cheap enough for health checks.
+func (m *Migrator) Pending(ctx context.Context) ([]string, error) {
+	var exists int
+	err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", m.store.Tablename()).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	if exists == 0 {
+		for _, source := range m.provider.ListSources() {
+			pending = append(pending, filepath.Base(source.Path))
+		}
+		return pending, nil
+	}
+
+	migrations, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range migrations {
+		if migration.State == goose.StatePending {
+			pending = append(pending, filepath.Base(migration.Source.Path))
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) down(ctx context.Context) (*goose.MigrationResult, error) {
+	result, err := m.provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return nil, ErrNoMigration
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.log(result)
+	return result, nil
+}
+
+func (m *Migrator) log(results ...*goose.MigrationResult) {
+	if m.Logf == nil {
+		return
+	}
+	for _, result := range results {
+		m.Logf("migrated %s %s", filepath.Base(result.Source.Path), result.Direction)
+	}
+}
+
+// withLock runs fn while a dedicated connection holds the migration lock.
+// The lock belongs to that connection, so it is released even if the
+// process dies.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.LockTimeout.Seconds())).Scan(&locked); err != nil {
+		return err
+	}
+	if locked.Int64 != 1 {
+		return ErrLockTimeout
+	}
+	defer func() {
+		if _, releaseErr := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"store-management/internal/datasource"
+	"store-management/tools/migrations"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_EmbeddedMigrations(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/store")
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := New(db, migrations.FS)
+	require.NoError(t, err)
+	assert.Equal(t, defaultLockTimeout, migrator.LockTimeout)
+	assert.NotEmpty(t, migrator.provider.ListSources())
+}
+
+func TestNew_NoMigrations(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/store")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = New(db, fstest.MapFS{})
+	assert.ErrorIs(t, err, goose.ErrNoMigrations)
+}
+
+// testMigrations create their own tables and are recorded in their own
+// version table, so the MySQL tests leave the migrations of the database
+// alone.
+var testMigrations = fstest.MapFS{
+	"00001_create_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE migrate_test_a (id BIGINT);\n\n-- +goose Down\nDROP TABLE migrate_test_a;\n")},
+	"00002_create_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE migrate_test_b (id BIGINT);\n\n-- +goose Down\nDROP TABLE migrate_test_b;\n")},
+}
+
+const testVersionTable = "migrate_test_db_version"
+
+// testDB connects to the database given by the TEST_DB_* variables, like the
+// repository contract suite, and skips the test without them. The tables of
+// testMigrations are dropped before and after the test.
+func testDB(t *testing.T) *sql.DB {
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+	db, err := datasource.NewMySQL(&datasource.MySQLConfig{
+		User:   os.Getenv("TEST_DB_USER"),
+		Passwd: os.Getenv("TEST_DB_PASS"),
+		Host:   host,
+		Port:   os.Getenv("TEST_DB_PORT"),
+		DBName: os.Getenv("TEST_DB_DBNAME"),
+	})
+	require.NoError(t, err)
+
+	dropTables := func() {
+		_, err := db.DB.Exec("DROP TABLE IF EXISTS migrate_test_a, migrate_test_b, " + testVersionTable)
+		require.NoError(t, err)
+	}
+	dropTables()
+	t.Cleanup(func() {
+		dropTables()
+		db.Close()
+	})
+	return db.DB.DB
+}
+
+func newTestMigrator(t *testing.T, db *sql.DB) *Migrator {
+	migrator, err := newMigrator(db, testMigrations, testVersionTable)
+	require.NoError(t, err)
+	return migrator
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", table).Scan(&count)
+	require.NoError(t, err)
+	return count > 0
+}
+
+func TestMigrator_UpStatusDown(t *testing.T) {
+	db := testDB(t)
+	migrator := newTestMigrator(t, db)
+	var logged []string
+	migrator.Logf = func(format string, args ...interface{}) {
+		logged = append(logged, args[0].(string)+" "+args[1].(string))
+	}
+	ctx := context.Background()
+
+	pending, err := migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00001_create_a.sql", "00002_create_b.sql"}, pending)
+	assert.False(t, tableExists(t, db, testVersionTable), "Pending must not create the version table")
+
+	require.NoError(t, migrator.Up(ctx))
+	assert.Equal(t, []string{"00001_create_a.sql up", "00002_create_b.sql up"}, logged)
+	assert.True(t, tableExists(t, db, "migrate_test_a"))
+	assert.True(t, tableExists(t, db, "migrate_test_b"))
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, status.Name)
+	}
+	pending, err = migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	logged = nil
+	require.NoError(t, migrator.Redo(ctx))
+	assert.Equal(t, []string{"00002_create_b.sql down", "00002_create_b.sql up"}, logged)
+	assert.True(t, tableExists(t, db, "migrate_test_b"))
+
+	require.NoError(t, migrator.Down(ctx))
+	assert.False(t, tableExists(t, db, "migrate_test_b"))
+	pending, err = migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00002_create_b.sql"}, pending)
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+
+	require.NoError(t, migrator.Down(ctx))
+	assert.ErrorIs(t, migrator.Down(ctx), ErrNoMigration)
+	assert.ErrorIs(t, migrator.Redo(ctx), ErrNoMigration)
+}
+
+func TestMigrator_WaitsForLock(t *testing.T) {
+	db := testDB(t)
+	holder := newTestMigrator(t, db)
+	waiter := newTestMigrator(t, db)
+	waiter.LockTimeout = time.Second
+	ctx := context.Background()
+
+	err := holder.withLock(ctx, func() error {
+		return waiter.Up(ctx)
+	})
+	assert.ErrorIs(t, err, ErrLockTimeout)
+	assert.False(t, tableExists(t, db, "migrate_test_a"))
+
+	require.NoError(t, waiter.Up(ctx))
+	pending, err := holder.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+)
+
+var validScopes = map[string]struct{}{
+	ScopeProductsRead:  {},
+	ScopeProductsWrite: {},
+}
+
+func IsValidScope(scope string) bool {
+	_, ok := validScopes[scope]
+	return ok
+}
+
+// Scopes is stored as a comma separated list.
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+func (s *Scopes) Scan(src interface{}) error {
+	var value string
+	switch v := src.(type) {
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	case nil:
+	default:
+		return errors.New("unsupported type for scopes")
+	}
+
+	*s = Scopes{}
+	if value == "" {
+		return nil
+	}
+	*s = strings.Split(value, ",")
+	return nil
+}
+
+func (s Scopes) Has(scope string) bool {
+	for _, v := range s {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKey struct {
+	ID         int64      `db:"id" json:"id"`
+	StoreID    int64      `db:"store_id" json:"store_id"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     Scopes     `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+func (k *APIKey) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !k.Scopes.Has(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !k.ExpiresAt.After(now)
+}
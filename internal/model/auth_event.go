@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type AuthEventType string
+
+const (
+	AuthEventLoginSucceeded AuthEventType = "login_succeeded"
+	AuthEventLockedOut      AuthEventType = "locked_out"
+)
+
+type AuthEvent struct {
+	ID          int64         `db:"id"`
+	UserID      *int64        `db:"user_id"`
+	PhoneNumber string        `db:"phone_number"`
+	Type        AuthEventType `db:"type"`
+	IP          string        `db:"ip"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+// LoginAttempts counts consecutive failed logins for a phone number or a
+// client IP. No login is accepted for it before BlockedUntil.
+type LoginAttempts struct {
+	Failures     int
+	BlockedUntil time.Time
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Session is one refresh token. Every rotation adds a session to the family
+// of the login it descends from.
+type Session struct {
+	ID        int64      `db:"id"`
+	FamilyID  string     `db:"family_id"`
+	UserID    int64      `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RotatedAt *time.Time `db:"rotated_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// DeviceSession is a login as the user sees it: one refresh token family and
+// the device it was started on.
+type DeviceSession struct {
+	ID          int64      `db:"id" json:"id"`
+	FamilyID    string     `db:"family_id" json:"-"`
+	UserID      int64      `db:"user_id" json:"-"`
+	DeviceLabel string     `db:"device_label" json:"device_label"`
+	UserAgent   string     `db:"user_agent" json:"user_agent"`
+	IP          string     `db:"ip" json:"ip"`
+	LastSeenAt  time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	RevokedAt   *time.Time `db:"revoked_at" json:"-"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	// Current marks the session the request was made with.
+	Current bool `db:"-" json:"current"`
+}
+
+// SessionClient describes the client a session is started from.
+type SessionClient struct {
+	DeviceLabel string
+	UserAgent   string
+	IP          string
+}
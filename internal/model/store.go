@@ -1,6 +1,60 @@
 package model
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const DefaultTimezone = "Asia/Seoul"
+
+type BusinessHour struct {
+	Day   string `json:"day"`
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// BusinessHours is stored as a JSON array.
+type BusinessHours []BusinessHour
+
+func (b BusinessHours) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (b *BusinessHours) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, b)
+	case string:
+		return json.Unmarshal([]byte(v), b)
+	case nil:
+		*b = nil
+		return nil
+	default:
+		return errors.New("unsupported type for business hours")
+	}
+}
+
 type Store struct {
-	ID     int64 `db:"id"`
-	UserID int64 `db:"user_id"`
+	ID            int64         `db:"id" json:"id"`
+	UserID        int64         `db:"user_id" json:"-"`
+	Name          string        `db:"name" json:"name"`
+	Address       string        `db:"address" json:"address"`
+	Phone         string        `db:"phone" json:"phone"`
+	BusinessHours BusinessHours `db:"business_hours" json:"business_hours"`
+	Timezone      string        `db:"timezone" json:"timezone"`
+	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time     `db:"updated_at" json:"updated_at"`
+
+	// Role is the role of the requesting user, filled when stores are listed
+	// through their memberships.
+	Role Role `db:"role" json:"role,omitempty"`
 }
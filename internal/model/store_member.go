@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+type Role string
+
+const (
+	RoleOwner   Role = "owner"
+	RoleManager Role = "manager"
+	RoleStaff   Role = "staff"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleOwner || r == RoleManager || r == RoleStaff
+}
+
+type StoreMember struct {
+	ID          int64     `db:"id" json:"id"`
+	StoreID     int64     `db:"store_id" json:"store_id"`
+	UserID      int64     `db:"user_id" json:"user_id"`
+	PhoneNumber string    `db:"phone_number" json:"phone_number"`
+	Role        Role      `db:"role" json:"role"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+type StoreInvitation struct {
+	ID          int64      `db:"id" json:"id"`
+	StoreID     int64      `db:"store_id" json:"store_id"`
+	PhoneNumber string     `db:"phone_number" json:"phone_number"`
+	Role        Role       `db:"role" json:"role"`
+	InvitedBy   int64      `db:"invited_by" json:"invited_by"`
+	ExpiresAt   time.Time  `db:"expires_at" json:"expires_at"`
+	AcceptedAt  *time.Time `db:"accepted_at" json:"accepted_at"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
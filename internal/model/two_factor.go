@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// TOTP is the authenticator app enrollment of a user. It only guards logins
+// once EnabledAt is set, after the user proved the app works.
+type TOTP struct {
+	UserID       int64      `db:"user_id"`
+	Secret       string     `db:"secret"`
+	LastUsedStep int64      `db:"last_used_step"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+func (t *TOTP) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// LoginChallenge is a login that passed the password check and waits for a
+// second factor.
+type LoginChallenge struct {
+	UserID      int64
+	Client      SessionClient
+	ReturnToken bool
+	ExpiresAt   time.Time
+}
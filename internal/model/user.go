@@ -1,7 +1,12 @@
 package model
 
+import "time"
+
 type User struct {
 	ID          int64  `db:"id"`
 	PhoneNumber string `db:"phone_number"`
 	Password    string `db:"password"`
+	// TokensValidAfter rejects access tokens issued before it, set when the
+	// password changes so that every existing token stops working.
+	TokensValidAfter *time.Time `db:"tokens_valid_after"`
 }
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+type VerificationPurpose string
+
+const (
+	VerificationPurposeRegister      VerificationPurpose = "register"
+	VerificationPurposePasswordReset VerificationPurpose = "password_reset"
+)
+
+// VerificationCode is a one-time code sent to a phone number. Only the hash
+// of the code is kept.
+type VerificationCode struct {
+	PhoneNumber string
+	Purpose     VerificationPurpose
+	CodeHash    string
+	Attempts    int
+	SentAt      time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,63 @@
+package permission
+
+import "store-management/internal/model"
+
+type Permission string
+
+const (
+	StoreRead       Permission = "store:read"
+	StoreUpdate     Permission = "store:update"
+	StoreDelete     Permission = "store:delete"
+	ProductRead     Permission = "product:read"
+	ProductViewCost Permission = "product:view_cost"
+	ProductCreate   Permission = "product:create"
+	ProductUpdate   Permission = "product:update"
+	ProductDelete   Permission = "product:delete"
+	MemberManage    Permission = "member:manage"
+	APIKeyManage    Permission = "api_key:manage"
+)
+
+type Set map[Permission]struct{}
+
+func newSet(permissions ...Permission) Set {
+	set := make(Set, len(permissions))
+	for _, p := range permissions {
+		set[p] = struct{}{}
+	}
+	return set
+}
+
+func (s Set) Has(p Permission) bool {
+	_, ok := s[p]
+	return ok
+}
+
+var rolePermissions = map[model.Role]Set{
+	model.RoleOwner:   newSet(StoreRead, StoreUpdate, StoreDelete, ProductRead, ProductViewCost, ProductCreate, ProductUpdate, ProductDelete, MemberManage, APIKeyManage),
+	model.RoleManager: newSet(StoreRead, StoreUpdate, ProductRead, ProductViewCost, ProductCreate, ProductUpdate, ProductDelete),
+	model.RoleStaff:   newSet(StoreRead, ProductRead, ProductUpdate),
+}
+
+var scopePermissions = map[string][]Permission{
+	model.ScopeProductsRead:  {ProductRead, ProductViewCost},
+	model.ScopeProductsWrite: {ProductCreate, ProductUpdate, ProductDelete},
+}
+
+// ForRole returns what a store member with the given role may do.
+func ForRole(role model.Role) Set {
+	if set, ok := rolePermissions[role]; ok {
+		return set
+	}
+	return Set{}
+}
+
+// ForScopes returns what an API key holding the given scopes may do.
+func ForScopes(scopes model.Scopes) Set {
+	set := Set{}
+	for _, scope := range scopes {
+		for _, p := range scopePermissions[scope] {
+			set[p] = struct{}{}
+		}
+	}
+	return set
+}
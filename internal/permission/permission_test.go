@@ -0,0 +1,37 @@
+package permission
+
+import (
+	"store-management/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForRole(t *testing.T) {
+	owner := ForRole(model.RoleOwner)
+	assert.True(t, owner.Has(MemberManage))
+	assert.True(t, owner.Has(ProductViewCost))
+
+	manager := ForRole(model.RoleManager)
+	assert.True(t, manager.Has(ProductDelete))
+	assert.False(t, manager.Has(MemberManage))
+
+	staff := ForRole(model.RoleStaff)
+	assert.True(t, staff.Has(ProductRead))
+	assert.True(t, staff.Has(ProductUpdate))
+	assert.False(t, staff.Has(ProductViewCost))
+	assert.False(t, staff.Has(ProductDelete))
+
+	assert.Empty(t, ForRole("unknown"))
+}
+
+func TestForScopes(t *testing.T) {
+	read := ForScopes(model.Scopes{model.ScopeProductsRead})
+	assert.True(t, read.Has(ProductRead))
+	assert.False(t, read.Has(ProductDelete))
+
+	write := ForScopes(model.Scopes{model.ScopeProductsRead, model.ScopeProductsWrite})
+	assert.True(t, write.Has(ProductDelete))
+	assert.False(t, write.Has(MemberManage))
+	assert.False(t, write.Has(APIKeyManage))
+}
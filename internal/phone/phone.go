@@ -0,0 +1,60 @@
+package phone
+
+import (
+	"store-management/internal/apperror"
+	"strings"
+)
+
+var ErrInvalidPhoneNumber = apperror.New(apperror.KindValidation, "invalid phone number")
+
+const koreaCountryCode = "82"
+
+// Normalize converts a phone number to E.164. Numbers written in the Korean
+// domestic format (010-1234-5678, 02-123-4567, ...) are assumed to be Korean;
+// anything else must carry an explicit country code (+82 10 1234 5678).
+func Normalize(raw string) (string, error) {
+	var digits strings.Builder
+	international := false
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == '-' || r == ' ' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+	number := digits.String()
+
+	if !international {
+		if !strings.HasPrefix(number, "0") {
+			return "", ErrInvalidPhoneNumber
+		}
+		number = koreaCountryCode + number[1:]
+	}
+
+	if strings.HasPrefix(number, koreaCountryCode) {
+		national := strings.TrimPrefix(number[len(koreaCountryCode):], "0")
+		if !validKoreanNationalNumber(national) {
+			return "", ErrInvalidPhoneNumber
+		}
+		return "+" + koreaCountryCode + national, nil
+	}
+
+	// E.164 allows at most 15 digits including the country code.
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", ErrInvalidPhoneNumber
+	}
+	return "+" + number, nil
+}
+
+func validKoreanNationalNumber(national string) bool {
+	// Mobile numbers: 10-1234-5678, 11-123-4567, 16/17/18/19-...
+	if strings.HasPrefix(national, "1") && len(national) >= 2 && strings.ContainsRune("016789", rune(national[1])) {
+		return len(national) == 9 || len(national) == 10
+	}
+	// Seoul (2) and other area codes (31-64) and nationwide numbers (70, 50x, ...).
+	return len(national) >= 8 && len(national) <= 10 && national[0] != '1'
+}
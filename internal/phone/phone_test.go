@@ -0,0 +1,34 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"010-1234-5678":     "+821012345678",
+		"01012345678":       "+821012345678",
+		"010 1234 5678":     "+821012345678",
+		"011-123-4567":      "+82111234567",
+		"+82 10-1234-5678":  "+821012345678",
+		"+82 010-1234-5678": "+821012345678",
+		"+821012345678":     "+821012345678",
+		"02-123-4567":       "+8221234567",
+		"031-1234-5678":     "+823112345678",
+		"+1 (415) 555-2671": "+14155552671",
+	}
+	for raw, expected := range cases {
+		normalized, err := Normalize(raw)
+		assert.NoError(t, err, raw)
+		assert.Equal(t, expected, normalized, raw)
+	}
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	for _, raw := range []string{"", "1234", "010-1234", "010-1234-56789", "1012345678", "phone", "+0 123 4567 890", "010+1234+5678"} {
+		_, err := Normalize(raw)
+		assert.ErrorIs(t, err, ErrInvalidPhoneNumber, raw)
+	}
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) (int64, error)
+	FindAPIKey(ctx context.Context, storeId, keyId int64) (*model.APIKey, error)
+	FindAPIKeysByStoreID(ctx context.Context, storeId int64) ([]*model.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	UpdateAPIKey(ctx context.Context, key *model.APIKey) error
+	DeleteAPIKey(ctx context.Context, storeId, keyId int64) error
+	TouchAPIKey(ctx context.Context, keyId int64, usedAt time.Time) error
+}
+
+type apiKeyRepositoryImpl struct {
+	writer datasource.SQL
+	reader datasource.SQL
+}
+
+func NewAPIKeyRepository(writer, reader datasource.SQL) APIKeyRepository {
+	return &apiKeyRepositoryImpl{
+		writer: writer,
+		reader: reader,
+	}
+}
+
+func (a *apiKeyRepositoryImpl) CreateAPIKey(ctx context.Context, key *model.APIKey) (int64, error) {
+	res, err := a.writer.ExecContext(ctx, "INSERT INTO api_key (store_id, name, prefix, key_hash, scopes, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		key.StoreID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (a *apiKeyRepositoryImpl) FindAPIKey(ctx context.Context, storeId, keyId int64) (*model.APIKey, error) {
+	var key model.APIKey
+	err := a.reader.GetContext(ctx, &key, "SELECT * FROM api_key WHERE id = ? AND store_id = ?", keyId, storeId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (a *apiKeyRepositoryImpl) FindAPIKeysByStoreID(ctx context.Context, storeId int64) ([]*model.APIKey, error) {
+	keys := []*model.APIKey{}
+	err := a.reader.SelectContext(ctx, &keys, "SELECT * FROM api_key WHERE store_id = ? ORDER BY id DESC", storeId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (a *apiKeyRepositoryImpl) FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	// Read from the writer so that a key deleted a moment ago stops working immediately.
+	err := a.writer.GetContext(ctx, &key, "SELECT * FROM api_key WHERE key_hash = ?", keyHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (a *apiKeyRepositoryImpl) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	res, err := a.writer.ExecContext(ctx, "UPDATE api_key SET name = ?, scopes = ?, expires_at = ? WHERE id = ? AND store_id = ?",
+		key.Name, key.Scopes, key.ExpiresAt, key.ID, key.StoreID)
+	if err != nil {
+		return err
+	}
+	if affectedRows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
+
+func (a *apiKeyRepositoryImpl) DeleteAPIKey(ctx context.Context, storeId, keyId int64) error {
+	res, err := a.writer.ExecContext(ctx, "DELETE FROM api_key WHERE id = ? AND store_id = ?", keyId, storeId)
+	if err != nil {
+		return err
+	}
+	if affectedRows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
+
+func (a *apiKeyRepositoryImpl) TouchAPIKey(ctx context.Context, keyId int64, usedAt time.Time) error {
+	_, err := a.writer.ExecContext(ctx, "UPDATE api_key SET last_used_at = ? WHERE id = ?", usedAt, keyId)
+	return err
+}
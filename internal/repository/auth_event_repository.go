@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+)
+
+type AuthEventRepository interface {
+	CreateAuthEvent(ctx context.Context, event *model.AuthEvent) error
+}
+
+type authEventRepositoryImpl struct {
+	writer datasource.SQL
+	reader datasource.SQL
+}
+
+func NewAuthEventRepository(writer, reader datasource.SQL) AuthEventRepository {
+	return &authEventRepositoryImpl{
+		writer: writer,
+		reader: reader,
+	}
+}
+
+func (a *authEventRepositoryImpl) CreateAuthEvent(ctx context.Context, event *model.AuthEvent) error {
+	res, err := a.writer.ExecContext(ctx, "INSERT INTO auth_event (user_id, phone_number, type, ip) VALUES (?, ?, ?, ?)",
+		event.UserID, event.PhoneNumber, event.Type, event.IP)
+	if err != nil {
+		return err
+	}
+	event.ID, err = res.LastInsertId()
+	return err
+}
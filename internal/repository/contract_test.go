@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// ContractSuite holds the behavior every Repository implementation must
+// share. It only adds rows it cleans up again, so it can run against a
+// migrated development database.
+type ContractSuite struct {
+	suite.Suite
+	newRepository func() Repository
+	repo          Repository
+	ctx           context.Context
+}
+
+func (s *ContractSuite) SetupTest() {
+	s.repo = s.newRepository()
+	s.ctx = context.Background()
+}
+
+var contractPhoneNumberSeq = time.Now().UnixNano() % 100000000
+
+func (s *ContractSuite) createUser() *model.User {
+	phoneNumber := fmt.Sprintf("+8210%08d", atomic.AddInt64(&contractPhoneNumberSeq, 1)%100000000)
+	s.Require().NoError(s.repo.UserRepository().CreateUser(s.ctx, phoneNumber, "password"))
+	user, err := s.repo.UserRepository().FindUser(s.ctx, phoneNumber)
+	s.Require().NoError(err)
+	s.T().Cleanup(func() {
+		_ = s.repo.UserRepository().DeleteUser(context.Background(), user)
+	})
+	return user
+}
+
+func (s *ContractSuite) createStore(user *model.User) int64 {
+	storeId, err := s.repo.StoreRepository().CreateStore(s.ctx, &model.Store{UserID: user.ID, Name: "store", Timezone: model.DefaultTimezone})
+	s.Require().NoError(err)
+	return storeId
+}
+
+func (s *ContractSuite) createProduct(storeId int64, name string) int64 {
+	productId, err := s.repo.ProductRepository().CreateProduct(s.ctx, storeId, &model.Product{
+		Category:   "drink",
+		Name:       name,
+		Price:      3000,
+		Cost:       1000,
+		ExpiryDate: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		Size:       "small",
+	})
+	s.Require().NoError(err)
+	return productId
+}
+
+func (s *ContractSuite) productIDs(products []*model.Product) []int64 {
+	ids := []int64{}
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+func (s *ContractSuite) TestUser_CreateAndFind() {
+	user := s.createUser()
+
+	found, err := s.repo.UserRepository().FindUserByID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Equal(user.PhoneNumber, found.PhoneNumber)
+	s.Equal("password", found.Password)
+	s.Nil(found.TokensValidAfter)
+
+	err = s.repo.UserRepository().CreateUser(s.ctx, user.PhoneNumber, "other")
+	s.ErrorIs(err, datasource.ErrDuplicateEntry)
+
+	_, err = s.repo.UserRepository().FindUser(s.ctx, "+820000000000")
+	s.ErrorIs(err, datasource.ErrNoRows)
+	_, err = s.repo.UserRepository().FindUserByID(s.ctx, -1)
+	s.ErrorIs(err, datasource.ErrNoRows)
+}
+
+func (s *ContractSuite) TestUser_UpdatePasswordAndInvalidateTokens() {
+	user := s.createUser()
+	issuedBefore := time.Now()
+
+	s.Require().NoError(s.repo.UserRepository().UpdatePassword(s.ctx, user.ID, "new-password"))
+	s.Require().NoError(s.repo.UserRepository().InvalidateAuthTokens(s.ctx, user.ID, issuedBefore))
+
+	found, err := s.repo.UserRepository().FindUserByID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Equal("new-password", found.Password)
+	s.Require().NotNil(found.TokensValidAfter)
+	s.True(found.TokensValidAfter.Equal(issuedBefore.Truncate(time.Second)))
+}
+
+func (s *ContractSuite) TestUser_DeleteRemovesOwnedStores() {
+	user := s.createUser()
+	storeId := s.createStore(user)
+	productId := s.createProduct(storeId, "아메리카노")
+
+	s.Require().NoError(s.repo.UserRepository().DeleteUser(s.ctx, user))
+
+	_, err := s.repo.UserRepository().FindUserByID(s.ctx, user.ID)
+	s.ErrorIs(err, datasource.ErrNoRows)
+	_, err = s.repo.StoreRepository().FindStore(s.ctx, storeId)
+	s.ErrorIs(err, datasource.ErrNoRows)
+	_, err = s.repo.ProductRepository().FindProduct(s.ctx, storeId, productId)
+	s.ErrorIs(err, datasource.ErrNoRows)
+	s.ErrorIs(s.repo.UserRepository().DeleteUser(s.ctx, user), datasource.ErrNoRows)
+}
+
+func (s *ContractSuite) TestStore_CreateFindUpdate() {
+	user := s.createUser()
+	storeId := s.createStore(user)
+
+	store, err := s.repo.StoreRepository().FindStore(s.ctx, storeId)
+	s.Require().NoError(err)
+	s.Equal(user.ID, store.UserID)
+	s.Equal("store", store.Name)
+	s.Equal(model.DefaultTimezone, store.Timezone)
+
+	stores, err := s.repo.StoreRepository().FindStoresByUserID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Require().Len(stores, 1)
+	s.Equal(storeId, stores[0].ID)
+	s.Equal(model.RoleOwner, stores[0].Role)
+
+	store.Name = "renamed"
+	store.BusinessHours = model.BusinessHours{{Day: "mon", Open: "09:00", Close: "18:00"}}
+	s.Require().NoError(s.repo.StoreRepository().UpdateStore(s.ctx, store))
+	store, err = s.repo.StoreRepository().FindStore(s.ctx, storeId)
+	s.Require().NoError(err)
+	s.Equal("renamed", store.Name)
+	s.Equal(model.BusinessHours{{Day: "mon", Open: "09:00", Close: "18:00"}}, store.BusinessHours)
+
+	s.ErrorIs(s.repo.StoreRepository().UpdateStore(s.ctx, &model.Store{ID: -1}), datasource.ErrNoRows)
+	_, err = s.repo.StoreRepository().FindStore(s.ctx, -1)
+	s.ErrorIs(err, datasource.ErrNoRows)
+}
+
+func (s *ContractSuite) TestStore_DeleteRemovesProducts() {
+	user := s.createUser()
+	storeId := s.createStore(user)
+	productId := s.createProduct(storeId, "아메리카노")
+
+	s.Require().NoError(s.repo.StoreRepository().DeleteStore(s.ctx, storeId))
+
+	_, err := s.repo.StoreRepository().FindStore(s.ctx, storeId)
+	s.ErrorIs(err, datasource.ErrNoRows)
+	_, err = s.repo.ProductRepository().FindProduct(s.ctx, storeId, productId)
+	s.ErrorIs(err, datasource.ErrNoRows)
+	stores, err := s.repo.StoreRepository().FindStoresByUserID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Empty(stores)
+	s.ErrorIs(s.repo.StoreRepository().DeleteStore(s.ctx, storeId), datasource.ErrNoRows)
+}
+
+func (s *ContractSuite) TestProduct_CreateFindUpdateDelete() {
+	user := s.createUser()
+	storeId := s.createStore(user)
+	otherStoreId := s.createStore(user)
+	productId := s.createProduct(storeId, "아메리카노")
+
+	product, err := s.repo.ProductRepository().FindProduct(s.ctx, storeId, productId)
+	s.Require().NoError(err)
+	s.Equal("아메리카노", product.Name)
+	s.Equal(3000.0, product.Price)
+	s.True(product.ExpiryDate.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)))
+	_, err = s.repo.ProductRepository().FindProduct(s.ctx, otherStoreId, productId)
+	s.ErrorIs(err, datasource.ErrNoRows)
+
+	s.Require().NoError(s.repo.ProductRepository().UpdateProduct(s.ctx, storeId, &model.Product{ID: productId, Price: 3500}))
+	product, err = s.repo.ProductRepository().FindProduct(s.ctx, storeId, productId)
+	s.Require().NoError(err)
+	s.Equal(3500.0, product.Price)
+	s.Equal("아메리카노", product.Name)
+	s.Equal("small", product.Size)
+	s.ErrorIs(s.repo.ProductRepository().UpdateProduct(s.ctx, otherStoreId, &model.Product{ID: productId, Price: 1}), datasource.ErrNoRows)
+
+	// Expiry dates must mean the same instant whatever the zone they were
+	// written in.
+	expiryDate := time.Date(2031, 5, 6, 7, 8, 9, 0, time.FixedZone("", -5*60*60))
+	s.Require().NoError(s.repo.ProductRepository().UpdateProduct(s.ctx, storeId, &model.Product{ID: productId, ExpiryDate: expiryDate}))
+	product, err = s.repo.ProductRepository().FindProduct(s.ctx, storeId, productId)
+	s.Require().NoError(err)
+	s.True(product.ExpiryDate.Equal(expiryDate), "expiry date %s, want %s", product.ExpiryDate, expiryDate)
+
+	s.ErrorIs(s.repo.ProductRepository().DeleteProduct(s.ctx, otherStoreId, productId), datasource.ErrNoRows)
+	s.Require().NoError(s.repo.ProductRepository().DeleteProduct(s.ctx, storeId, productId))
+	_, err = s.repo.ProductRepository().FindProduct(s.ctx, storeId, productId)
+	s.ErrorIs(err, datasource.ErrNoRows)
+	s.ErrorIs(s.repo.ProductRepository().DeleteProduct(s.ctx, storeId, productId), datasource.ErrNoRows)
+}
+
+func (s *ContractSuite) TestProduct_CursorPagination() {
+	user := s.createUser()
+	storeId := s.createStore(user)
+	otherStoreId := s.createStore(user)
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, s.createProduct(storeId, fmt.Sprintf("product %d", i)))
+	}
+	s.createProduct(otherStoreId, "other")
+
+	page, err := s.repo.ProductRepository().FindProductsWithPagination(s.ctx, storeId, 0, 2)
+	s.Require().NoError(err)
+	s.Equal([]int64{ids[4], ids[3]}, s.productIDs(page))
+
+	page, err = s.repo.ProductRepository().FindProductsWithPagination(s.ctx, storeId, ids[3], 2)
+	s.Require().NoError(err)
+	s.Equal([]int64{ids[2], ids[1]}, s.productIDs(page))
+
+	page, err = s.repo.ProductRepository().FindProductsWithPagination(s.ctx, storeId, ids[1], 2)
+	s.Require().NoError(err)
+	s.Equal([]int64{ids[0]}, s.productIDs(page))
+
+	page, err = s.repo.ProductRepository().FindProductsWithPagination(s.ctx, storeId, ids[0], 2)
+	s.Require().NoError(err)
+	s.Empty(page)
+}
+
+func (s *ContractSuite) TestProduct_Search() {
+	user := s.createUser()
+	storeId := s.createStore(user)
+	americano := s.createProduct(storeId, "아메리카노")
+	latte := s.createProduct(storeId, "카페라떼")
+	greenTea := s.createProduct(storeId, "Green Tea")
+	s.createProduct(s.createStore(user), "아이스 아메리카노")
+
+	cases := map[string][]int64{
+		"ㅇㅁㄹ":   {americano},
+		"라떼":    {latte},
+		"ㅋ":     {latte, americano},
+		"green": {greenTea},
+		"없음":    {},
+	}
+	for keyword, expected := range cases {
+		products, err := s.repo.ProductRepository().SearchProducts(s.ctx, storeId, keyword)
+		s.Require().NoError(err)
+		s.Equal(expected, s.productIDs(products), keyword)
+	}
+
+	s.Require().NoError(s.repo.ProductRepository().UpdateProduct(s.ctx, storeId, &model.Product{ID: greenTea, Name: "녹차"}))
+	products, err := s.repo.ProductRepository().SearchProducts(s.ctx, storeId, "ㄴㅊ")
+	s.Require().NoError(err)
+	s.Equal([]int64{greenTea}, s.productIDs(products))
+}
+
+// Session times are compared with the clock, so they must mean the same
+// instant whatever the zone they were created in.
+func (s *ContractSuite) TestSession_ExpiresAtKeepsInstant() {
+	user := s.createUser()
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tokenHash := fmt.Sprintf("%064d", user.ID)
+
+	err := s.repo.SessionRepository().CreateSession(s.ctx, &model.Session{FamilyID: tokenHash, UserID: user.ID, TokenHash: tokenHash, ExpiresAt: expiresAt}, &model.DeviceSession{})
+	s.Require().NoError(err)
+
+	session, err := s.repo.SessionRepository().FindSessionByTokenHash(s.ctx, tokenHash)
+	s.Require().NoError(err)
+	s.True(session.ExpiresAt.Equal(expiresAt), "expires at %s, want %s", session.ExpiresAt, expiresAt)
+}
+
+// A verified token is consumed once, even by concurrent requests.
+func (s *ContractSuite) TestVerification_ConsumeVerifiedTokenOnce() {
+	verification := s.repo.VerificationRepository()
+	s.Require().NoError(verification.SaveVerifiedToken(s.ctx, model.VerificationPurposeRegister, "+821012345678", "token", time.Minute))
+
+	var (
+		wg       sync.WaitGroup
+		consumed atomic.Int32
+	)
+	start := make(chan struct{})
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ok, err := verification.ConsumeVerifiedToken(s.ctx, model.VerificationPurposeRegister, "+821012345678", "token")
+			s.NoError(err)
+			if ok {
+				consumed.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	s.Equal(int32(1), consumed.Load())
+}
+
+func (s *ContractSuite) TestTransaction_ComposesRepositories() {
+	user := s.createUser()
+	failure := errors.New("failure")
+
+	err := s.repo.Transaction().WithinTx(s.ctx, func(ctx context.Context, _ datasource.TxExecer) error {
+		if _, err := s.repo.StoreRepository().CreateStore(ctx, &model.Store{UserID: user.ID, Name: "rolled back", Timezone: model.DefaultTimezone}); err != nil {
+			return err
+		}
+		if err := s.repo.UserRepository().UpdatePassword(ctx, user.ID, "rolled back"); err != nil {
+			return err
+		}
+		return failure
+	})
+	s.ErrorIs(err, failure)
+
+	stores, err := s.repo.StoreRepository().FindStoresByUserID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Empty(stores)
+	found, err := s.repo.UserRepository().FindUserByID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Equal("password", found.Password)
+
+	err = s.repo.Transaction().WithinTx(s.ctx, func(ctx context.Context, _ datasource.TxExecer) error {
+		if _, err := s.repo.StoreRepository().CreateStore(ctx, &model.Store{UserID: user.ID, Name: "committed", Timezone: model.DefaultTimezone}); err != nil {
+			return err
+		}
+		return s.repo.UserRepository().UpdatePassword(ctx, user.ID, "committed")
+	})
+	s.Require().NoError(err)
+
+	stores, err = s.repo.StoreRepository().FindStoresByUserID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Len(stores, 1)
+	found, err = s.repo.UserRepository().FindUserByID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Equal("committed", found.Password)
+}
+
+func TestInMemoryContract(t *testing.T) {
+	suite.Run(t, &ContractSuite{newRepository: func() Repository {
+		return NewInMemory(datasource.NewInMemoryCache())
+	}})
+}
+
+// TestMySQLContract runs against the database given by the TEST_DB_*
+// variables, migrated to the latest version, and is skipped without them.
+func TestMySQLContract(t *testing.T) {
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+	db, err := datasource.NewMySQL(&datasource.MySQLConfig{
+		User:   os.Getenv("TEST_DB_USER"),
+		Passwd: os.Getenv("TEST_DB_PASS"),
+		Host:   host,
+		Port:   os.Getenv("TEST_DB_PORT"),
+		DBName: os.Getenv("TEST_DB_DBNAME"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	suite.Run(t, &ContractSuite{newRepository: func() Repository {
+		return New(db, db, db, datasource.NewInMemoryCache())
+	}})
+}
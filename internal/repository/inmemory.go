@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"sync"
+	"time"
+)
+
+// memoryTable is a table with an auto increment primary key.
+type memoryTable[T any] struct {
+	lastID int64
+	rows   map[int64]T
+}
+
+func newMemoryTable[T any]() memoryTable[T] {
+	return memoryTable[T]{rows: map[int64]T{}}
+}
+
+func (t *memoryTable[T]) nextID() int64 {
+	t.lastID++
+	return t.lastID
+}
+
+func (t memoryTable[T]) clone() memoryTable[T] {
+	rows := make(map[int64]T, len(t.rows))
+	for id, row := range t.rows {
+		rows[id] = row
+	}
+	return memoryTable[T]{lastID: t.lastID, rows: rows}
+}
+
+type memoryRecoveryCode struct {
+	UserID   int64
+	CodeHash string
+	UsedAt   *time.Time
+}
+
+type memoryTables struct {
+	users          memoryTable[model.User]
+	stores         memoryTable[model.Store]
+	products       memoryTable[model.Product]
+	productStores  map[int64]int64 // product id to store id, the store_product table
+	members        memoryTable[model.StoreMember]
+	invitations    memoryTable[model.StoreInvitation]
+	apiKeys        memoryTable[model.APIKey]
+	authEvents     memoryTable[model.AuthEvent]
+	sessions       memoryTable[model.Session]
+	deviceSessions memoryTable[model.DeviceSession]
+	totps          map[int64]model.TOTP // keyed by user id
+	recoveryCodes  memoryTable[memoryRecoveryCode]
+}
+
+func (t *memoryTables) clone() memoryTables {
+	productStores := make(map[int64]int64, len(t.productStores))
+	for productId, storeId := range t.productStores {
+		productStores[productId] = storeId
+	}
+	totps := make(map[int64]model.TOTP, len(t.totps))
+	for userId, totp := range t.totps {
+		totps[userId] = totp
+	}
+	return memoryTables{
+		users:          t.users.clone(),
+		stores:         t.stores.clone(),
+		products:       t.products.clone(),
+		productStores:  productStores,
+		members:        t.members.clone(),
+		invitations:    t.invitations.clone(),
+		apiKeys:        t.apiKeys.clone(),
+		authEvents:     t.authEvents.clone(),
+		sessions:       t.sessions.clone(),
+		deviceSessions: t.deviceSessions.clone(),
+		totps:          totps,
+		recoveryCodes:  t.recoveryCodes.clone(),
+	}
+}
+
+type memoryTxContextKey struct{}
+
+// memoryDB holds the tables of the in-memory repositories. Single writes are
+// atomic, and WithinTx serializes units of work with every write made outside
+// of them, restoring a snapshot of the tables on rollback. Reads outside of a
+// unit of work may see its uncommitted writes.
+type memoryDB struct {
+	txMu   sync.Mutex
+	mu     sync.RWMutex
+	tables memoryTables
+}
+
+func newMemoryDB() *memoryDB {
+	return &memoryDB{
+		tables: memoryTables{
+			users:          newMemoryTable[model.User](),
+			stores:         newMemoryTable[model.Store](),
+			products:       newMemoryTable[model.Product](),
+			productStores:  map[int64]int64{},
+			members:        newMemoryTable[model.StoreMember](),
+			invitations:    newMemoryTable[model.StoreInvitation](),
+			apiKeys:        newMemoryTable[model.APIKey](),
+			authEvents:     newMemoryTable[model.AuthEvent](),
+			sessions:       newMemoryTable[model.Session](),
+			deviceSessions: newMemoryTable[model.DeviceSession](),
+			totps:          map[int64]model.TOTP{},
+			recoveryCodes:  newMemoryTable[memoryRecoveryCode](),
+		},
+	}
+}
+
+// WithinTx runs fn as a unit of work. The in-memory repositories take no
+// statements, so fn is given a nil TxExecer and isolation options are ignored.
+func (db *memoryDB) WithinTx(ctx context.Context, fn datasource.TxFunc, opts ...datasource.TxOption) (err error) {
+	if ctx.Value(memoryTxContextKey{}) != nil {
+		return fn(ctx, nil)
+	}
+
+	db.txMu.Lock()
+	defer db.txMu.Unlock()
+	db.mu.RLock()
+	snapshot := db.tables.clone()
+	db.mu.RUnlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			db.restore(snapshot)
+			err = fmt.Errorf("%w: %v", datasource.ErrTxPanic, r)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, memoryTxContextKey{}, db), nil); err != nil {
+		db.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+func (db *memoryDB) restore(snapshot memoryTables) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tables = snapshot
+}
+
+func (db *memoryDB) read(fn func(t *memoryTables) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return fn(&db.tables)
+}
+
+// write runs fn with the tables locked. fn must check everything that can
+// fail before it changes a table, as nothing is undone when it returns an
+// error.
+func (db *memoryDB) write(ctx context.Context, fn func(t *memoryTables) error) error {
+	if ctx.Value(memoryTxContextKey{}) == nil {
+		db.txMu.Lock()
+		defer db.txMu.Unlock()
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return fn(&db.tables)
+}
+
+// memoryNow is the current time at the precision of a MySQL TIMESTAMP.
+func memoryNow() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+// NewInMemory returns repositories keeping everything in process memory with
+// the semantics of the MySQL ones. Nothing survives a restart.
+func NewInMemory(cache datasource.Cache) Repository {
+	db := newMemoryDB()
+	return &repositoryImpl{
+		transaction: db,
+		cache:       cache,
+
+		user:         &memoryUserRepository{db: db, cache: cache},
+		store:        &memoryStoreRepository{db: db},
+		product:      &memoryProductRepository{db: db},
+		session:      &memorySessionRepository{db: db},
+		apiKey:       &memoryAPIKeyRepository{db: db},
+		member:       &memoryMemberRepository{db: db},
+		verification: NewVerificationRepository(cache),
+		authEvent:    &memoryAuthEventRepository{db: db},
+		loginAttempt: NewLoginAttemptRepository(cache),
+		twoFactor:    &memoryTwoFactorRepository{db: db, challenges: &twoFactorRepositoryImpl{cache: cache}},
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type memoryAPIKeyRepository struct {
+	db *memoryDB
+}
+
+func cloneAPIKey(key *model.APIKey) model.APIKey {
+	row := *key
+	row.Scopes = append(model.Scopes{}, key.Scopes...)
+	if key.ExpiresAt != nil {
+		expiresAt := key.ExpiresAt.Truncate(time.Second)
+		row.ExpiresAt = &expiresAt
+	}
+	return row
+}
+
+func (a *memoryAPIKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (int64, error) {
+	var keyId int64
+	err := a.db.write(ctx, func(t *memoryTables) error {
+		for _, existing := range t.apiKeys.rows {
+			if existing.KeyHash == key.KeyHash {
+				return datasource.ErrDuplicateEntry
+			}
+		}
+		row := cloneAPIKey(key)
+		row.LastUsedAt = nil
+		row.CreatedAt = memoryNow()
+		keyId = t.apiKeys.nextID()
+		row.ID = keyId
+		t.apiKeys.rows[keyId] = row
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return keyId, nil
+}
+
+func (a *memoryAPIKeyRepository) FindAPIKey(ctx context.Context, storeId, keyId int64) (*model.APIKey, error) {
+	var found *model.APIKey
+	err := a.db.read(func(t *memoryTables) error {
+		key, ok := t.apiKeys.rows[keyId]
+		if !ok || key.StoreID != storeId {
+			return datasource.ErrNoRows
+		}
+		found = &key
+		return nil
+	})
+	return found, err
+}
+
+func (a *memoryAPIKeyRepository) FindAPIKeysByStoreID(ctx context.Context, storeId int64) ([]*model.APIKey, error) {
+	keys := []*model.APIKey{}
+	err := a.db.read(func(t *memoryTables) error {
+		for _, key := range t.apiKeys.rows {
+			if key.StoreID == storeId {
+				key := key
+				keys = append(keys, &key)
+			}
+		}
+		return nil
+	})
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID > keys[j].ID })
+	return keys, err
+}
+
+func (a *memoryAPIKeyRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var found *model.APIKey
+	err := a.db.read(func(t *memoryTables) error {
+		for _, key := range t.apiKeys.rows {
+			if key.KeyHash == keyHash {
+				found = &key
+				return nil
+			}
+		}
+		return datasource.ErrNoRows
+	})
+	return found, err
+}
+
+func (a *memoryAPIKeyRepository) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	return a.db.write(ctx, func(t *memoryTables) error {
+		row, ok := t.apiKeys.rows[key.ID]
+		if !ok || row.StoreID != key.StoreID {
+			return datasource.ErrNoRows
+		}
+		updated := cloneAPIKey(key)
+		row.Name, row.Scopes, row.ExpiresAt = updated.Name, updated.Scopes, updated.ExpiresAt
+		t.apiKeys.rows[key.ID] = row
+		return nil
+	})
+}
+
+func (a *memoryAPIKeyRepository) DeleteAPIKey(ctx context.Context, storeId, keyId int64) error {
+	return a.db.write(ctx, func(t *memoryTables) error {
+		key, ok := t.apiKeys.rows[keyId]
+		if !ok || key.StoreID != storeId {
+			return datasource.ErrNoRows
+		}
+		delete(t.apiKeys.rows, keyId)
+		return nil
+	})
+}
+
+func (a *memoryAPIKeyRepository) TouchAPIKey(ctx context.Context, keyId int64, usedAt time.Time) error {
+	return a.db.write(ctx, func(t *memoryTables) error {
+		if key, ok := t.apiKeys.rows[keyId]; ok {
+			usedAt := usedAt.Truncate(time.Second)
+			key.LastUsedAt = &usedAt
+			t.apiKeys.rows[keyId] = key
+		}
+		return nil
+	})
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"store-management/internal/model"
+)
+
+type memoryAuthEventRepository struct {
+	db *memoryDB
+}
+
+func (a *memoryAuthEventRepository) CreateAuthEvent(ctx context.Context, event *model.AuthEvent) error {
+	return a.db.write(ctx, func(t *memoryTables) error {
+		row := *event
+		if event.UserID != nil {
+			userId := *event.UserID
+			row.UserID = &userId
+		}
+		row.CreatedAt = memoryNow()
+		event.ID = t.authEvents.nextID()
+		row.ID = event.ID
+		t.authEvents.rows[event.ID] = row
+		return nil
+	})
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type memoryMemberRepository struct {
+	db *memoryDB
+}
+
+// member joins the phone number of the user like the MySQL queries do. It
+// returns false for a membership of a user that no longer exists.
+func (t *memoryTables) member(member model.StoreMember) (*model.StoreMember, bool) {
+	user, ok := t.users.rows[member.UserID]
+	if !ok {
+		return nil, false
+	}
+	member.PhoneNumber = user.PhoneNumber
+	return &member, true
+}
+
+func (m *memoryMemberRepository) FindMember(ctx context.Context, storeId, userId int64) (*model.StoreMember, error) {
+	var found *model.StoreMember
+	err := m.db.read(func(t *memoryTables) error {
+		for _, member := range t.members.rows {
+			if member.StoreID == storeId && member.UserID == userId {
+				if joined, ok := t.member(member); ok {
+					found = joined
+					return nil
+				}
+			}
+		}
+		return datasource.ErrNoRows
+	})
+	return found, err
+}
+
+func (m *memoryMemberRepository) FindMembersByStoreID(ctx context.Context, storeId int64) ([]*model.StoreMember, error) {
+	members := m.findMembers(func(member *model.StoreMember) bool {
+		return member.StoreID == storeId
+	})
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members, nil
+}
+
+// FindMembershipsByUserID lists the stores the user belongs to, owned stores
+// first.
+func (m *memoryMemberRepository) FindMembershipsByUserID(ctx context.Context, userId int64) ([]*model.StoreMember, error) {
+	members := m.findMembers(func(member *model.StoreMember) bool {
+		return member.UserID == userId
+	})
+	sort.Slice(members, func(i, j int) bool {
+		if owner := members[i].Role == model.RoleOwner; owner != (members[j].Role == model.RoleOwner) {
+			return owner
+		}
+		return members[i].ID < members[j].ID
+	})
+	return members, nil
+}
+
+func (m *memoryMemberRepository) findMembers(match func(member *model.StoreMember) bool) []*model.StoreMember {
+	members := []*model.StoreMember{}
+	_ = m.db.read(func(t *memoryTables) error {
+		for _, member := range t.members.rows {
+			if !match(&member) {
+				continue
+			}
+			if joined, ok := t.member(member); ok {
+				members = append(members, joined)
+			}
+		}
+		return nil
+	})
+	return members
+}
+
+func (m *memoryMemberRepository) DeleteMember(ctx context.Context, storeId, userId int64) error {
+	return m.db.write(ctx, func(t *memoryTables) error {
+		for id, member := range t.members.rows {
+			if member.StoreID == storeId && member.UserID == userId {
+				delete(t.members.rows, id)
+				return nil
+			}
+		}
+		return datasource.ErrNoRows
+	})
+}
+
+func (m *memoryMemberRepository) CreateInvitation(ctx context.Context, invitation *model.StoreInvitation) (int64, error) {
+	var invitationId int64
+	err := m.db.write(ctx, func(t *memoryTables) error {
+		row := *invitation
+		row.ExpiresAt = row.ExpiresAt.Truncate(time.Second)
+		row.AcceptedAt = nil
+		row.CreatedAt = memoryNow()
+		invitationId = t.invitations.nextID()
+		row.ID = invitationId
+		t.invitations.rows[invitationId] = row
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return invitationId, nil
+}
+
+func (m *memoryMemberRepository) FindInvitation(ctx context.Context, invitationId int64) (*model.StoreInvitation, error) {
+	var found *model.StoreInvitation
+	err := m.db.read(func(t *memoryTables) error {
+		invitation, ok := t.invitations.rows[invitationId]
+		if !ok {
+			return datasource.ErrNoRows
+		}
+		found = &invitation
+		return nil
+	})
+	return found, err
+}
+
+func (m *memoryMemberRepository) FindPendingInvitationsByStoreID(ctx context.Context, storeId int64) ([]*model.StoreInvitation, error) {
+	return m.findPendingInvitations(func(invitation *model.StoreInvitation) bool {
+		return invitation.StoreID == storeId
+	}), nil
+}
+
+func (m *memoryMemberRepository) FindPendingInvitationsByPhoneNumber(ctx context.Context, phoneNumber string) ([]*model.StoreInvitation, error) {
+	return m.findPendingInvitations(func(invitation *model.StoreInvitation) bool {
+		return invitation.PhoneNumber == phoneNumber
+	}), nil
+}
+
+// findPendingInvitations returns the unaccepted, unexpired invitations
+// accepted by match, newest first.
+func (m *memoryMemberRepository) findPendingInvitations(match func(invitation *model.StoreInvitation) bool) []*model.StoreInvitation {
+	invitations := []*model.StoreInvitation{}
+	_ = m.db.read(func(t *memoryTables) error {
+		now := time.Now()
+		for _, invitation := range t.invitations.rows {
+			if invitation.AcceptedAt == nil && invitation.ExpiresAt.After(now) && match(&invitation) {
+				invitation := invitation
+				invitations = append(invitations, &invitation)
+			}
+		}
+		return nil
+	})
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].ID > invitations[j].ID })
+	return invitations
+}
+
+func (m *memoryMemberRepository) AcceptInvitation(ctx context.Context, invitation *model.StoreInvitation, userId int64) error {
+	return m.db.write(ctx, func(t *memoryTables) error {
+		row, ok := t.invitations.rows[invitation.ID]
+		if !ok || row.AcceptedAt != nil {
+			return datasource.ErrNoRows
+		}
+		for _, member := range t.members.rows {
+			if member.StoreID == invitation.StoreID && member.UserID == userId {
+				return datasource.ErrDuplicateEntry
+			}
+		}
+
+		now := memoryNow()
+		row.AcceptedAt = &now
+		t.invitations.rows[invitation.ID] = row
+		memberId := t.members.nextID()
+		t.members.rows[memberId] = model.StoreMember{ID: memberId, StoreID: invitation.StoreID, UserID: userId, Role: invitation.Role, CreatedAt: now}
+		return nil
+	})
+}
+
+func (m *memoryMemberRepository) DeleteInvitation(ctx context.Context, storeId, invitationId int64) error {
+	return m.db.write(ctx, func(t *memoryTables) error {
+		invitation, ok := t.invitations.rows[invitationId]
+		if !ok || invitation.StoreID != storeId || invitation.AcceptedAt != nil {
+			return datasource.ErrNoRows
+		}
+		delete(t.invitations.rows, invitationId)
+		return nil
+	})
+}
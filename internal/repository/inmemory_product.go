@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"strings"
+	"time"
+)
+
+type memoryProductRepository struct {
+	db *memoryDB
+}
+
+func (p *memoryProductRepository) CreateProduct(ctx context.Context, storeId int64, product *model.Product) (int64, error) {
+	product.AbstractName = extractAbstractKoreanName(product.Name)
+	var productId int64
+	err := p.db.write(ctx, func(t *memoryTables) error {
+		row := *product
+		row.ExpiryDate = row.ExpiryDate.Truncate(time.Second)
+		productId = t.products.nextID()
+		row.ID = productId
+		t.products.rows[productId] = row
+		t.productStores[productId] = storeId
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return productId, nil
+}
+
+// UpdateProduct sets the non-zero fields of product, like the MySQL
+// implementation.
+func (p *memoryProductRepository) UpdateProduct(ctx context.Context, storeId int64, product *model.Product) error {
+	return p.db.write(ctx, func(t *memoryTables) error {
+		row, ok := t.products.rows[product.ID]
+		if !ok || t.productStores[product.ID] != storeId {
+			return datasource.ErrNoRows
+		}
+
+		fields := reflect.ValueOf(product).Elem()
+		target := reflect.ValueOf(&row).Elem()
+		for i := 0; i < fields.NumField(); i++ {
+			field := fields.Field(i)
+			if field.IsZero() || fields.Type().Field(i).Name == "ID" {
+				continue
+			}
+			if fieldValue, ok := field.Interface().(time.Time); ok {
+				if fieldValue.Unix() == 0 {
+					continue
+				}
+				field = reflect.ValueOf(fieldValue.Truncate(time.Second))
+			}
+			target.Field(i).Set(field)
+		}
+		if product.Name != "" {
+			row.AbstractName = extractAbstractKoreanName(product.Name)
+		}
+		t.products.rows[product.ID] = row
+		return nil
+	})
+}
+
+func (p *memoryProductRepository) DeleteProduct(ctx context.Context, storeId, productId int64) error {
+	return p.db.write(ctx, func(t *memoryTables) error {
+		if productStoreId, ok := t.productStores[productId]; !ok || productStoreId != storeId {
+			return datasource.ErrNoRows
+		}
+		delete(t.productStores, productId)
+		delete(t.products.rows, productId)
+		return nil
+	})
+}
+
+func (p *memoryProductRepository) FindProduct(ctx context.Context, storeId, productId int64) (*model.Product, error) {
+	var found *model.Product
+	err := p.db.read(func(t *memoryTables) error {
+		product, ok := t.products.rows[productId]
+		if !ok || t.productStores[productId] != storeId {
+			return datasource.ErrNoRows
+		}
+		found = &product
+		return nil
+	})
+	return found, err
+}
+
+func (p *memoryProductRepository) FindProductsWithPagination(ctx context.Context, storeId int64, cursor int64, limit int64) ([]*model.Product, error) {
+	products := p.storeProducts(storeId, func(product *model.Product) bool {
+		return cursor <= 0 || product.ID < cursor
+	})
+	if limit < 0 {
+		limit = 0
+	}
+	if int64(len(products)) > limit {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+// SearchProducts matches the keyword, or its initial consonants, anywhere in
+// the name, ignoring case like the MySQL collation does.
+func (p *memoryProductRepository) SearchProducts(ctx context.Context, storeId int64, keyword string) ([]*model.Product, error) {
+	keyword = strings.ToLower(keyword)
+	abstractKeyword := strings.ToLower(extractAbstractKoreanName(keyword))
+	return p.storeProducts(storeId, func(product *model.Product) bool {
+		return strings.Contains(strings.ToLower(product.Name), keyword) ||
+			strings.Contains(strings.ToLower(product.AbstractName), abstractKeyword)
+	}), nil
+}
+
+// storeProducts returns the products of the store accepted by match, newest
+// first.
+func (p *memoryProductRepository) storeProducts(storeId int64, match func(product *model.Product) bool) []*model.Product {
+	products := []*model.Product{}
+	_ = p.db.read(func(t *memoryTables) error {
+		for productId, productStoreId := range t.productStores {
+			if productStoreId != storeId {
+				continue
+			}
+			product := t.products.rows[productId]
+			if match(&product) {
+				products = append(products, &product)
+			}
+		}
+		return nil
+	})
+	sort.Slice(products, func(i, j int) bool { return products[i].ID > products[j].ID })
+	return products
+}
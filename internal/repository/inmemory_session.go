@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type memorySessionRepository struct {
+	db *memoryDB
+}
+
+func (s *memorySessionRepository) CreateSession(ctx context.Context, session *model.Session, device *model.DeviceSession) error {
+	return s.db.write(ctx, func(t *memoryTables) error {
+		for _, existing := range t.deviceSessions.rows {
+			if existing.FamilyID == session.FamilyID {
+				return datasource.ErrDuplicateEntry
+			}
+		}
+		if t.hasSessionToken(session.TokenHash) {
+			return datasource.ErrDuplicateEntry
+		}
+
+		now := memoryNow()
+		device.ID = t.deviceSessions.nextID()
+		t.deviceSessions.rows[device.ID] = model.DeviceSession{
+			ID:          device.ID,
+			FamilyID:    session.FamilyID,
+			UserID:      session.UserID,
+			DeviceLabel: device.DeviceLabel,
+			UserAgent:   device.UserAgent,
+			IP:          device.IP,
+			LastSeenAt:  now,
+			CreatedAt:   now,
+		}
+		session.ID = t.insertSession(session, now)
+		return nil
+	})
+}
+
+func (t *memoryTables) hasSessionToken(tokenHash string) bool {
+	for _, session := range t.sessions.rows {
+		if session.TokenHash == tokenHash {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *memoryTables) insertSession(session *model.Session, now time.Time) int64 {
+	id := t.sessions.nextID()
+	t.sessions.rows[id] = model.Session{
+		ID:        id,
+		FamilyID:  session.FamilyID,
+		UserID:    session.UserID,
+		TokenHash: session.TokenHash,
+		ExpiresAt: session.ExpiresAt.Truncate(time.Second),
+		CreatedAt: now,
+	}
+	return id
+}
+
+func (s *memorySessionRepository) FindSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	var found *model.Session
+	err := s.db.read(func(t *memoryTables) error {
+		for _, session := range t.sessions.rows {
+			if session.TokenHash == tokenHash {
+				found = &session
+				return nil
+			}
+		}
+		return datasource.ErrNoRows
+	})
+	return found, err
+}
+
+func (s *memorySessionRepository) RotateSession(ctx context.Context, sessionId int64, next *model.Session) error {
+	return s.db.write(ctx, func(t *memoryTables) error {
+		session, ok := t.sessions.rows[sessionId]
+		if !ok || session.RotatedAt != nil || session.RevokedAt != nil {
+			return datasource.ErrNoRows
+		}
+		if t.hasSessionToken(next.TokenHash) {
+			return datasource.ErrDuplicateEntry
+		}
+
+		now := memoryNow()
+		session.RotatedAt = &now
+		t.sessions.rows[sessionId] = session
+		next.ID = t.insertSession(next, now)
+		return nil
+	})
+}
+
+func (s *memorySessionRepository) RevokeSessionFamily(ctx context.Context, familyId string) error {
+	return s.revoke(ctx, func(userId int64, sessionFamilyId string) bool {
+		return sessionFamilyId == familyId
+	})
+}
+
+func (s *memorySessionRepository) RevokeUserSessions(ctx context.Context, userId int64) error {
+	return s.revoke(ctx, func(sessionUserId int64, _ string) bool {
+		return sessionUserId == userId
+	})
+}
+
+func (s *memorySessionRepository) RevokeOtherSessions(ctx context.Context, userId int64, keepFamilyId string) error {
+	return s.revoke(ctx, func(sessionUserId int64, familyId string) bool {
+		return sessionUserId == userId && familyId != keepFamilyId
+	})
+}
+
+// revoke revokes the refresh tokens and device sessions matching where.
+func (s *memorySessionRepository) revoke(ctx context.Context, where func(userId int64, familyId string) bool) error {
+	return s.db.write(ctx, func(t *memoryTables) error {
+		now := memoryNow()
+		for id, session := range t.sessions.rows {
+			if session.RevokedAt == nil && where(session.UserID, session.FamilyID) {
+				session.RevokedAt = &now
+				t.sessions.rows[id] = session
+			}
+		}
+		for id, device := range t.deviceSessions.rows {
+			if device.RevokedAt == nil && where(device.UserID, device.FamilyID) {
+				device.RevokedAt = &now
+				t.deviceSessions.rows[id] = device
+			}
+		}
+		return nil
+	})
+}
+
+func (s *memorySessionRepository) FindDeviceSession(ctx context.Context, familyId string) (*model.DeviceSession, error) {
+	var found *model.DeviceSession
+	err := s.db.read(func(t *memoryTables) error {
+		for _, device := range t.deviceSessions.rows {
+			if device.FamilyID == familyId {
+				found = &device
+				return nil
+			}
+		}
+		return datasource.ErrNoRows
+	})
+	return found, err
+}
+
+func (s *memorySessionRepository) FindDeviceSessionByID(ctx context.Context, userId, id int64) (*model.DeviceSession, error) {
+	var found *model.DeviceSession
+	err := s.db.read(func(t *memoryTables) error {
+		device, ok := t.deviceSessions.rows[id]
+		if !ok || device.UserID != userId {
+			return datasource.ErrNoRows
+		}
+		found = &device
+		return nil
+	})
+	return found, err
+}
+
+// FindDeviceSessionsByUserID returns the sessions that can still be used: not
+// revoked and holding an unused refresh token that has not expired.
+func (s *memorySessionRepository) FindDeviceSessionsByUserID(ctx context.Context, userId int64) ([]*model.DeviceSession, error) {
+	devices := []*model.DeviceSession{}
+	err := s.db.read(func(t *memoryTables) error {
+		now := time.Now()
+		usable := map[string]bool{}
+		for _, session := range t.sessions.rows {
+			if session.RotatedAt == nil && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+				usable[session.FamilyID] = true
+			}
+		}
+		for _, device := range t.deviceSessions.rows {
+			if device.UserID == userId && device.RevokedAt == nil && usable[device.FamilyID] {
+				device := device
+				devices = append(devices, &device)
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(devices, func(i, j int) bool { return devices[i].LastSeenAt.After(devices[j].LastSeenAt) })
+	return devices, err
+}
+
+func (s *memorySessionRepository) TouchDeviceSession(ctx context.Context, familyId string, seenAt time.Time, ip string) error {
+	return s.db.write(ctx, func(t *memoryTables) error {
+		for id, device := range t.deviceSessions.rows {
+			if device.FamilyID == familyId {
+				device.LastSeenAt = seenAt.Truncate(time.Second)
+				device.IP = ip
+				t.deviceSessions.rows[id] = device
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+)
+
+type memoryStoreRepository struct {
+	db *memoryDB
+}
+
+func (s *memoryStoreRepository) CreateStore(ctx context.Context, store *model.Store) (int64, error) {
+	var storeId int64
+	err := s.db.write(ctx, func(t *memoryTables) error {
+		now := memoryNow()
+		row := *store
+		row.BusinessHours = cloneBusinessHours(store.BusinessHours)
+		row.Role = ""
+		row.CreatedAt, row.UpdatedAt = now, now
+		storeId = t.stores.nextID()
+		row.ID = storeId
+		t.stores.rows[storeId] = row
+
+		memberId := t.members.nextID()
+		t.members.rows[memberId] = model.StoreMember{ID: memberId, StoreID: storeId, UserID: store.UserID, Role: model.RoleOwner, CreatedAt: now}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return storeId, nil
+}
+
+func (s *memoryStoreRepository) FindStore(ctx context.Context, storeId int64) (*model.Store, error) {
+	var found *model.Store
+	err := s.db.read(func(t *memoryTables) error {
+		store, ok := t.stores.rows[storeId]
+		if !ok {
+			return datasource.ErrNoRows
+		}
+		found = &store
+		return nil
+	})
+	return found, err
+}
+
+func (s *memoryStoreRepository) FindStoresByUserID(ctx context.Context, userId int64) ([]*model.Store, error) {
+	stores := []*model.Store{}
+	err := s.db.read(func(t *memoryTables) error {
+		for _, member := range t.members.rows {
+			if member.UserID != userId {
+				continue
+			}
+			if store, ok := t.stores.rows[member.StoreID]; ok {
+				store.Role = member.Role
+				stores = append(stores, &store)
+			}
+		}
+		return nil
+	})
+	sort.Slice(stores, func(i, j int) bool { return stores[i].ID < stores[j].ID })
+	return stores, err
+}
+
+func (s *memoryStoreRepository) UpdateStore(ctx context.Context, store *model.Store) error {
+	return s.db.write(ctx, func(t *memoryTables) error {
+		row, ok := t.stores.rows[store.ID]
+		if !ok {
+			return datasource.ErrNoRows
+		}
+		row.Name = store.Name
+		row.Address = store.Address
+		row.Phone = store.Phone
+		row.BusinessHours = cloneBusinessHours(store.BusinessHours)
+		row.Timezone = store.Timezone
+		row.UpdatedAt = memoryNow()
+		t.stores.rows[store.ID] = row
+		return nil
+	})
+}
+
+func (s *memoryStoreRepository) DeleteStore(ctx context.Context, storeId int64) error {
+	return s.db.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.stores.rows[storeId]; !ok {
+			return datasource.ErrNoRows
+		}
+		t.deleteStore(storeId)
+		return nil
+	})
+}
+
+// deleteStore removes the store together with its products, members,
+// invitations and API keys.
+func (t *memoryTables) deleteStore(storeId int64) {
+	for productId, productStoreId := range t.productStores {
+		if productStoreId == storeId {
+			delete(t.products.rows, productId)
+			delete(t.productStores, productId)
+		}
+	}
+	for id, member := range t.members.rows {
+		if member.StoreID == storeId {
+			delete(t.members.rows, id)
+		}
+	}
+	for id, invitation := range t.invitations.rows {
+		if invitation.StoreID == storeId {
+			delete(t.invitations.rows, id)
+		}
+	}
+	for id, key := range t.apiKeys.rows {
+		if key.StoreID == storeId {
+			delete(t.apiKeys.rows, id)
+		}
+	}
+	delete(t.stores.rows, storeId)
+}
+
+func cloneBusinessHours(hours model.BusinessHours) model.BusinessHours {
+	if hours == nil {
+		return nil
+	}
+	return append(model.BusinessHours{}, hours...)
+}
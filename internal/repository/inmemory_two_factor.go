@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+)
+
+// memoryTwoFactorRepository keeps enrollments in memory and leaves pending
+// login challenges to the cache, like the MySQL implementation does.
+type memoryTwoFactorRepository struct {
+	db         *memoryDB
+	challenges *twoFactorRepositoryImpl
+}
+
+func (r *memoryTwoFactorRepository) FindTOTP(ctx context.Context, userId int64) (*model.TOTP, error) {
+	var found *model.TOTP
+	err := r.db.read(func(t *memoryTables) error {
+		totp, ok := t.totps[userId]
+		if !ok {
+			return datasource.ErrNoRows
+		}
+		found = &totp
+		return nil
+	})
+	return found, err
+}
+
+// SaveTOTP stores a pending enrollment, replacing a previous pending one.
+func (r *memoryTwoFactorRepository) SaveTOTP(ctx context.Context, totp *model.TOTP) error {
+	return r.db.write(ctx, func(t *memoryTables) error {
+		createdAt := memoryNow()
+		if existing, ok := t.totps[totp.UserID]; ok {
+			createdAt = existing.CreatedAt
+		}
+		t.totps[totp.UserID] = model.TOTP{UserID: totp.UserID, Secret: totp.Secret, CreatedAt: createdAt}
+		return nil
+	})
+}
+
+func (r *memoryTwoFactorRepository) EnableTOTP(ctx context.Context, userId int64, recoveryCodeHashes []string) error {
+	return r.db.write(ctx, func(t *memoryTables) error {
+		totp, ok := t.totps[userId]
+		if !ok || totp.EnabledAt != nil {
+			return datasource.ErrNoRows
+		}
+		seen := map[string]bool{}
+		for _, codeHash := range recoveryCodeHashes {
+			if seen[codeHash] {
+				return datasource.ErrDuplicateEntry
+			}
+			seen[codeHash] = true
+		}
+
+		now := memoryNow()
+		totp.EnabledAt = &now
+		t.totps[userId] = totp
+		t.deleteRecoveryCodes(userId)
+		for _, codeHash := range recoveryCodeHashes {
+			t.recoveryCodes.rows[t.recoveryCodes.nextID()] = memoryRecoveryCode{UserID: userId, CodeHash: codeHash}
+		}
+		return nil
+	})
+}
+
+func (r *memoryTwoFactorRepository) DeleteTOTP(ctx context.Context, userId int64) error {
+	return r.db.write(ctx, func(t *memoryTables) error {
+		t.deleteRecoveryCodes(userId)
+		delete(t.totps, userId)
+		return nil
+	})
+}
+
+func (t *memoryTables) deleteRecoveryCodes(userId int64) {
+	for id, code := range t.recoveryCodes.rows {
+		if code.UserID == userId {
+			delete(t.recoveryCodes.rows, id)
+		}
+	}
+}
+
+func (r *memoryTwoFactorRepository) UseTOTPStep(ctx context.Context, userId int64, step int64) error {
+	return r.db.write(ctx, func(t *memoryTables) error {
+		totp, ok := t.totps[userId]
+		if !ok || totp.LastUsedStep >= step {
+			return datasource.ErrNoRows
+		}
+		totp.LastUsedStep = step
+		t.totps[userId] = totp
+		return nil
+	})
+}
+
+func (r *memoryTwoFactorRepository) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) error {
+	return r.db.write(ctx, func(t *memoryTables) error {
+		for id, code := range t.recoveryCodes.rows {
+			if code.UserID == userId && code.CodeHash == codeHash && code.UsedAt == nil {
+				now := memoryNow()
+				code.UsedAt = &now
+				t.recoveryCodes.rows[id] = code
+				return nil
+			}
+		}
+		return datasource.ErrNoRows
+	})
+}
+
+func (r *memoryTwoFactorRepository) SaveLoginChallenge(ctx context.Context, tokenHash string, challenge *model.LoginChallenge) error {
+	return r.challenges.SaveLoginChallenge(ctx, tokenHash, challenge)
+}
+
+func (r *memoryTwoFactorRepository) FindLoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	return r.challenges.FindLoginChallenge(ctx, tokenHash)
+}
+
+func (r *memoryTwoFactorRepository) DeleteLoginChallenge(ctx context.Context, tokenHash string) {
+	r.challenges.DeleteLoginChallenge(ctx, tokenHash)
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type memoryUserRepository struct {
+	db    *memoryDB
+	cache datasource.Cache
+}
+
+func (u *memoryUserRepository) CreateUser(ctx context.Context, phoneNumber, password string) error {
+	return u.db.write(ctx, func(t *memoryTables) error {
+		for _, user := range t.users.rows {
+			if user.PhoneNumber == phoneNumber {
+				return datasource.ErrDuplicateEntry
+			}
+		}
+		id := t.users.nextID()
+		t.users.rows[id] = model.User{ID: id, PhoneNumber: phoneNumber, Password: password}
+		return nil
+	})
+}
+
+func (u *memoryUserRepository) FindUser(ctx context.Context, phoneNumber string) (*model.User, error) {
+	var found *model.User
+	err := u.db.read(func(t *memoryTables) error {
+		for _, user := range t.users.rows {
+			if user.PhoneNumber == phoneNumber {
+				found = &user
+				return nil
+			}
+		}
+		return datasource.ErrNoRows
+	})
+	return found, err
+}
+
+func (u *memoryUserRepository) FindUserByID(ctx context.Context, id int64) (*model.User, error) {
+	var found *model.User
+	err := u.db.read(func(t *memoryTables) error {
+		user, ok := t.users.rows[id]
+		if !ok {
+			return datasource.ErrNoRows
+		}
+		found = &user
+		return nil
+	})
+	return found, err
+}
+
+func (u *memoryUserRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	return u.db.write(ctx, func(t *memoryTables) error {
+		if user, ok := t.users.rows[id]; ok {
+			user.Password = password
+			t.users.rows[id] = user
+		}
+		return nil
+	})
+}
+
+func (u *memoryUserRepository) InvalidateAuthTokens(ctx context.Context, id int64, issuedBefore time.Time) error {
+	return u.db.write(ctx, func(t *memoryTables) error {
+		if user, ok := t.users.rows[id]; ok {
+			validAfter := issuedBefore.Truncate(time.Second)
+			user.TokensValidAfter = &validAfter
+			t.users.rows[id] = user
+		}
+		return nil
+	})
+}
+
+func (u *memoryUserRepository) BlockAuthToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return u.cache.Set(authTokenDenylistKeyPrefix+jti, struct{}{}, ttl)
+}
+
+func (u *memoryUserRepository) IsAuthTokenBlocked(ctx context.Context, jti string) (bool, error) {
+	value, err := u.cache.Get(authTokenDenylistKeyPrefix + jti)
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+func (u *memoryUserRepository) DeleteUser(ctx context.Context, user *model.User) error {
+	return u.db.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.users.rows[user.ID]; !ok {
+			return datasource.ErrNoRows
+		}
+		for id, store := range t.stores.rows {
+			if store.UserID == user.ID {
+				t.deleteStore(id)
+			}
+		}
+		for id, member := range t.members.rows {
+			if member.UserID == user.ID {
+				delete(t.members.rows, id)
+			}
+		}
+		for id, session := range t.sessions.rows {
+			if session.UserID == user.ID {
+				delete(t.sessions.rows, id)
+			}
+		}
+		for id, device := range t.deviceSessions.rows {
+			if device.UserID == user.ID {
+				delete(t.deviceSessions.rows, id)
+			}
+		}
+		for id, code := range t.recoveryCodes.rows {
+			if code.UserID == user.ID {
+				delete(t.recoveryCodes.rows, id)
+			}
+		}
+		delete(t.totps, user.ID)
+		for id, event := range t.authEvents.rows {
+			if event.UserID != nil && *event.UserID == user.ID {
+				event.PhoneNumber = ""
+				t.authEvents.rows[id] = event
+			}
+		}
+		for id, invitation := range t.invitations.rows {
+			if invitation.PhoneNumber == user.PhoneNumber && invitation.AcceptedAt == nil {
+				delete(t.invitations.rows, id)
+			}
+		}
+		delete(t.users.rows, user.ID)
+		return nil
+	})
+}
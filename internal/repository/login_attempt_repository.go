@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type LoginAttemptRepository interface {
+	FindLoginAttempts(ctx context.Context, key string) (*model.LoginAttempts, error)
+	SaveLoginAttempts(ctx context.Context, key string, attempts *model.LoginAttempts, ttl time.Duration) error
+	UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts *model.LoginAttempts) (time.Duration, error)) error
+	DeleteLoginAttempts(ctx context.Context, key string)
+}
+
+// loginAttemptRepositoryImpl keeps failed login counters in the cache only;
+// they are meant to expire on their own.
+type loginAttemptRepositoryImpl struct {
+	cache datasource.Cache
+}
+
+func NewLoginAttemptRepository(cache datasource.Cache) LoginAttemptRepository {
+	return &loginAttemptRepositoryImpl{
+		cache: cache,
+	}
+}
+
+func loginAttemptsKey(key string) string {
+	return fmt.Sprintf("login_attempts:%s", key)
+}
+
+// FindLoginAttempts returns the attempts recorded for key, or zero attempts
+// when there are none.
+func (l *loginAttemptRepositoryImpl) FindLoginAttempts(ctx context.Context, key string) (*model.LoginAttempts, error) {
+	value, err := l.cache.Get(loginAttemptsKey(key))
+	if err != nil {
+		return nil, err
+	}
+	attempts, _ := value.(model.LoginAttempts)
+	return &attempts, nil
+}
+
+func (l *loginAttemptRepositoryImpl) SaveLoginAttempts(ctx context.Context, key string, attempts *model.LoginAttempts, ttl time.Duration) error {
+	return l.cache.Set(loginAttemptsKey(key), *attempts, ttl)
+}
+
+// UpdateLoginAttempts atomically applies fn to the attempts recorded for key
+// and keeps the result for the ttl fn returns. Nothing is saved when fn
+// fails.
+func (l *loginAttemptRepositoryImpl) UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts *model.LoginAttempts) (time.Duration, error)) error {
+	return l.cache.Update(loginAttemptsKey(key), func(value interface{}) (interface{}, time.Duration, error) {
+		attempts, _ := value.(model.LoginAttempts)
+		ttl, err := fn(&attempts)
+		return attempts, ttl, err
+	})
+}
+
+func (l *loginAttemptRepositoryImpl) DeleteLoginAttempts(ctx context.Context, key string) {
+	l.cache.Invalidate(loginAttemptsKey(key))
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type MemberRepository interface {
+	FindMember(ctx context.Context, storeId, userId int64) (*model.StoreMember, error)
+	FindMembersByStoreID(ctx context.Context, storeId int64) ([]*model.StoreMember, error)
+	FindMembershipsByUserID(ctx context.Context, userId int64) ([]*model.StoreMember, error)
+	DeleteMember(ctx context.Context, storeId, userId int64) error
+
+	CreateInvitation(ctx context.Context, invitation *model.StoreInvitation) (int64, error)
+	FindInvitation(ctx context.Context, invitationId int64) (*model.StoreInvitation, error)
+	FindPendingInvitationsByStoreID(ctx context.Context, storeId int64) ([]*model.StoreInvitation, error)
+	FindPendingInvitationsByPhoneNumber(ctx context.Context, phoneNumber string) ([]*model.StoreInvitation, error)
+	AcceptInvitation(ctx context.Context, invitation *model.StoreInvitation, userId int64) error
+	DeleteInvitation(ctx context.Context, storeId, invitationId int64) error
+}
+
+type memberRepositoryImpl struct {
+	writer      datasource.SQL
+	reader      datasource.SQL
+	transaction datasource.Transaction
+}
+
+func NewMemberRepository(writer, reader datasource.SQL, transaction datasource.Transaction) MemberRepository {
+	return &memberRepositoryImpl{
+		writer:      writer,
+		reader:      reader,
+		transaction: transaction,
+	}
+}
+
+func (m *memberRepositoryImpl) FindMember(ctx context.Context, storeId, userId int64) (*model.StoreMember, error) {
+	var member model.StoreMember
+	err := m.reader.GetContext(ctx, &member, `
+        SELECT sm.id, sm.store_id, sm.user_id, u.phone_number, sm.role, sm.created_at FROM store_member sm
+        INNER JOIN user u ON u.id = sm.user_id
+        WHERE sm.store_id = ? AND sm.user_id = ?
+    `, storeId, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (m *memberRepositoryImpl) FindMembersByStoreID(ctx context.Context, storeId int64) ([]*model.StoreMember, error) {
+	members := []*model.StoreMember{}
+	err := m.reader.SelectContext(ctx, &members, `
+        SELECT sm.id, sm.store_id, sm.user_id, u.phone_number, sm.role, sm.created_at FROM store_member sm
+        INNER JOIN user u ON u.id = sm.user_id
+        WHERE sm.store_id = ?
+        ORDER BY sm.id
+    `, storeId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return members, nil
+}
+
+// FindMembershipsByUserID lists the stores the user belongs to, owned stores
+// first.
+func (m *memberRepositoryImpl) FindMembershipsByUserID(ctx context.Context, userId int64) ([]*model.StoreMember, error) {
+	members := []*model.StoreMember{}
+	err := m.reader.SelectContext(ctx, &members, `
+        SELECT sm.id, sm.store_id, sm.user_id, u.phone_number, sm.role, sm.created_at FROM store_member sm
+        INNER JOIN user u ON u.id = sm.user_id
+        WHERE sm.user_id = ?
+        ORDER BY sm.role = 'owner' DESC, sm.id
+    `, userId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (m *memberRepositoryImpl) DeleteMember(ctx context.Context, storeId, userId int64) error {
+	res, err := m.writer.ExecContext(ctx, "DELETE FROM store_member WHERE store_id = ? AND user_id = ?", storeId, userId)
+	if err != nil {
+		return err
+	}
+	if affectedRows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
+
+func (m *memberRepositoryImpl) CreateInvitation(ctx context.Context, invitation *model.StoreInvitation) (int64, error) {
+	res, err := m.writer.ExecContext(ctx, "INSERT INTO store_invitation (store_id, phone_number, role, invited_by, expires_at) VALUES (?, ?, ?, ?, ?)",
+		invitation.StoreID, invitation.PhoneNumber, invitation.Role, invitation.InvitedBy, invitation.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (m *memberRepositoryImpl) FindInvitation(ctx context.Context, invitationId int64) (*model.StoreInvitation, error) {
+	var invitation model.StoreInvitation
+	err := m.reader.GetContext(ctx, &invitation, "SELECT * FROM store_invitation WHERE id = ?", invitationId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (m *memberRepositoryImpl) FindPendingInvitationsByStoreID(ctx context.Context, storeId int64) ([]*model.StoreInvitation, error) {
+	invitations := []*model.StoreInvitation{}
+	err := m.reader.SelectContext(ctx, &invitations, "SELECT * FROM store_invitation WHERE store_id = ? AND accepted_at IS NULL AND expires_at > ? ORDER BY id DESC",
+		storeId, time.Now())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (m *memberRepositoryImpl) FindPendingInvitationsByPhoneNumber(ctx context.Context, phoneNumber string) ([]*model.StoreInvitation, error) {
+	invitations := []*model.StoreInvitation{}
+	err := m.reader.SelectContext(ctx, &invitations, "SELECT * FROM store_invitation WHERE phone_number = ? AND accepted_at IS NULL AND expires_at > ? ORDER BY id DESC",
+		phoneNumber, time.Now())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation marks the invitation as accepted and adds the user to the
+// store in a single transaction. It returns datasource.ErrNoRows when the
+// invitation was accepted concurrently and datasource.ErrDuplicateEntry when
+// the user is already a member.
+func (m *memberRepositoryImpl) AcceptInvitation(ctx context.Context, invitation *model.StoreInvitation, userId int64) error {
+	return m.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		res, err := tx.ExecContext(ctx, "UPDATE store_invitation SET accepted_at = NOW() WHERE id = ? AND accepted_at IS NULL", invitation.ID)
+		if err != nil {
+			return err
+		}
+		if err := expectAffected(res); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO store_member (store_id, user_id, role) VALUES (?, ?, ?)", invitation.StoreID, userId, invitation.Role)
+		if err != nil {
+			var mysqlError *mysql.MySQLError
+			if errors.As(err, &mysqlError) && mysqlError.Number == datasource.MySQLDuplicateEntry {
+				return datasource.ErrDuplicateEntry
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (m *memberRepositoryImpl) DeleteInvitation(ctx context.Context, storeId, invitationId int64) error {
+	res, err := m.writer.ExecContext(ctx, "DELETE FROM store_invitation WHERE id = ? AND store_id = ? AND accepted_at IS NULL", invitationId, storeId)
+	if err != nil {
+		return err
+	}
+	if affectedRows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
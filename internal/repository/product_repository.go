@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,12 +13,12 @@ import (
 )
 
 type ProductRepository interface {
-	CreateProduct(storeId int64, product *model.Product) (int64, error)
-	UpdateProduct(storeId int64, product *model.Product) error
-	DeleteProduct(storeId, productId int64) error
-	FindProduct(storeId, productId int64) (*model.Product, error)
-	FindProductsWithPagination(storeId int64, cursor int64, limit int64) ([]*model.Product, error)
-	SearchProducts(storeId int64, query string) ([]*model.Product, error)
+	CreateProduct(ctx context.Context, storeId int64, product *model.Product) (int64, error)
+	UpdateProduct(ctx context.Context, storeId int64, product *model.Product) error
+	DeleteProduct(ctx context.Context, storeId, productId int64) error
+	FindProduct(ctx context.Context, storeId, productId int64) (*model.Product, error)
+	FindProductsWithPagination(ctx context.Context, storeId int64, cursor int64, limit int64) ([]*model.Product, error)
+	SearchProducts(ctx context.Context, storeId int64, query string) ([]*model.Product, error)
 }
 type productRepositoryImpl struct {
 	writer      datasource.SQL
@@ -35,7 +36,9 @@ func NewProductRepository(writer, reader datasource.SQL, transaction datasource.
 
 var initialKoreanRunes = [19]rune{'ㄱ', 'ㄲ', 'ㄴ', 'ㄷ', 'ㄸ', 'ㄹ', 'ㅁ', 'ㅂ', 'ㅃ', 'ㅅ', 'ㅆ', 'ㅇ', 'ㅈ', 'ㅉ', 'ㅊ', 'ㅋ', 'ㅌ', 'ㅍ', 'ㅎ'}
 
-func (p *productRepositoryImpl) extractAbstractKoreanName(text string) string {
+// extractAbstractKoreanName replaces every Hangul syllable of text with its
+// initial consonant, which is what choseong search matches against.
+func extractAbstractKoreanName(text string) string {
 	var abstractName strings.Builder
 	for _, r := range text {
 		if r >= '가' && r <= '힣' {
@@ -48,108 +51,107 @@ func (p *productRepositoryImpl) extractAbstractKoreanName(text string) string {
 	return abstractName.String()
 }
 
-func (p *productRepositoryImpl) CreateProduct(storeId int64, product *model.Product) (int64, error) {
-	tx := p.transaction.MustBegin()
-	product.AbstractName = p.extractAbstractKoreanName(product.Name)
-	res := tx.MustExec("INSERT INTO product (category, price, cost, name, abstract_name, description, barcode, expiry_date, size) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-		product.Category, product.Price, product.Cost, product.Name, product.AbstractName, product.Description, product.Barcode, product.ExpiryDate.Format("2006-01-02 15:04:05"), product.Size)
-	lastInsertId, err := res.LastInsertId()
-	if err != nil {
-		_ = tx.Rollback()
-		return 0, err
-	}
-	tx.MustExec("INSERT INTO store_product (store_id, product_id) VALUES (?, ?)", storeId, lastInsertId)
-	err = tx.Commit()
+func (p *productRepositoryImpl) CreateProduct(ctx context.Context, storeId int64, product *model.Product) (int64, error) {
+	product.AbstractName = extractAbstractKoreanName(product.Name)
+	var productId int64
+	err := p.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		res, err := tx.ExecContext(ctx, "INSERT INTO product (category, price, cost, name, abstract_name, description, barcode, expiry_date, size) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			product.Category, product.Price, product.Cost, product.Name, product.AbstractName, product.Description, product.Barcode, product.ExpiryDate, product.Size)
+		if err != nil {
+			return err
+		}
+		if productId, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO store_product (store_id, product_id) VALUES (?, ?)", storeId, productId)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
-	return lastInsertId, nil
+	return productId, nil
 }
 
-func (p *productRepositoryImpl) UpdateProduct(storeId int64, product *model.Product) error {
-	tx := p.transaction.MustBegin()
-	var count int
-	if err := tx.Get(&count, "SELECT COUNT(id) FROM store_product WHERE store_id = ? AND product_id = ?", storeId, product.ID); err != nil || count == 0 {
-		_ = tx.Rollback()
-		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				return err
-			}
-			panic(err)
-		} else {
-			return sql.ErrNoRows
-		}
-	}
-
-	fields := reflect.ValueOf(product).Elem()
-	for i := 0; i < fields.NumField(); i++ {
-		field := fields.Field(i)
-		if field.IsZero() {
-			continue
+// UpdateProduct sets the non-zero fields of product. It returns
+// datasource.ErrNoRows when the product is not in the store.
+func (p *productRepositoryImpl) UpdateProduct(ctx context.Context, storeId int64, product *model.Product) error {
+	return p.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		var count int
+		if err := tx.GetContext(ctx, &count, "SELECT COUNT(id) FROM store_product WHERE store_id = ? AND product_id = ?", storeId, product.ID); err != nil {
+			return err
 		}
-		fieldName := fields.Type().Field(i).Tag.Get("db")
-		if fieldName == "ID" {
-			continue
+		if count == 0 {
+			return datasource.ErrNoRows
 		}
-		fieldValue := field.Interface()
-		if fields.Type().Field(i).Type.String() == "time.Time" {
-			if fieldValue.(time.Time).Unix() == 0 {
+
+		fields := reflect.ValueOf(product).Elem()
+		for i := 0; i < fields.NumField(); i++ {
+			field := fields.Field(i)
+			if field.IsZero() {
 				continue
 			}
-			fieldValue = fieldValue.(time.Time).Format("2006-01-02 15:04:05")
-		}
+			fieldName := fields.Type().Field(i).Tag.Get("db")
+			if fieldName == "ID" {
+				continue
+			}
+			fieldValue := field.Interface()
+			if fields.Type().Field(i).Type.String() == "time.Time" {
+				if fieldValue.(time.Time).Unix() == 0 {
+					continue
+				}
+			}
 
-		_ = tx.MustExec(fmt.Sprintf("UPDATE product SET %s = ? WHERE id = ?", fieldName), fieldValue, product.ID)
-		if fieldName == "name" {
-			_ = tx.MustExec("UPDATE product SET abstract_name = ? WHERE id = ?", p.extractAbstractKoreanName(fieldValue.(string)), product.ID)
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE product SET %s = ? WHERE id = ?", fieldName), fieldValue, product.ID); err != nil {
+				return err
+			}
+			if fieldName == "name" {
+				if _, err := tx.ExecContext(ctx, "UPDATE product SET abstract_name = ? WHERE id = ?", extractAbstractKoreanName(fieldValue.(string)), product.ID); err != nil {
+					return err
+				}
+			}
 		}
-	}
-
-	err := tx.Commit()
-	if err != nil {
-		panic(err)
-	}
-	return nil
+		return nil
+	})
 }
 
-func (p *productRepositoryImpl) DeleteProduct(storeId, productId int64) error {
-	tx := p.transaction.MustBegin()
-	_, err := tx.Exec("DELETE FROM product WHERE id = ?", productId)
-	if err != nil {
-		_ = tx.Rollback()
+// DeleteProduct returns datasource.ErrNoRows when the product is not in the
+// store.
+func (p *productRepositoryImpl) DeleteProduct(ctx context.Context, storeId, productId int64) error {
+	return p.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM store_product WHERE store_id = ? AND product_id = ?", storeId, productId)
+		if err != nil {
+			return err
+		}
+		if err := expectAffected(res); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM product WHERE id = ?", productId)
 		return err
-	}
-	res := tx.MustExec("DELETE FROM store_product WHERE store_id = ? AND product_id = ?", storeId, productId)
-	affectedRows, err := res.RowsAffected()
-	if affectedRows == 0 || err != nil {
-		_ = tx.Rollback()
-		return errors.New("product not found")
-	}
-	return tx.Commit()
+	})
 }
 
-func (p *productRepositoryImpl) FindProduct(storeId, productId int64) (*model.Product, error) {
+func (p *productRepositoryImpl) FindProduct(ctx context.Context, storeId, productId int64) (*model.Product, error) {
 	var count int
-	err := p.reader.Get(&count, "SELECT count(id) FROM store_product WHERE store_id = ? AND product_id = ?", storeId, productId)
+	err := p.reader.GetContext(ctx, &count, "SELECT count(id) FROM store_product WHERE store_id = ? AND product_id = ?", storeId, productId)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	if count == 0 {
 		return nil, datasource.ErrNoRows
 	}
 
 	var product model.Product
-	err = p.reader.Get(&product, "SELECT * FROM product WHERE id = ?", productId)
+	err = p.reader.GetContext(ctx, &product, "SELECT * FROM product WHERE id = ?", productId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datasource.ErrNoRows
 		}
-		panic(err)
+		return nil, err
 	}
 	return &product, nil
 }
 
-func (p *productRepositoryImpl) FindProductsWithPagination(storeId int64, cursor int64, limit int64) ([]*model.Product, error) {
+func (p *productRepositoryImpl) FindProductsWithPagination(ctx context.Context, storeId int64, cursor int64, limit int64) ([]*model.Product, error) {
 	var products []*model.Product
 
 	var cursorCondition string
@@ -167,18 +169,18 @@ func (p *productRepositoryImpl) FindProductsWithPagination(storeId int64, cursor
 
 	var err error
 	if cursor > 0 {
-		err = p.reader.Select(&products, query, storeId, cursor, limit)
+		err = p.reader.SelectContext(ctx, &products, query, storeId, cursor, limit)
 	} else {
-		err = p.reader.Select(&products, query, storeId, limit)
+		err = p.reader.SelectContext(ctx, &products, query, storeId, limit)
 	}
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		panic(err)
+		return nil, err
 	}
 	return products, nil
 }
 
-func (p *productRepositoryImpl) SearchProducts(storeId int64, keyword string) ([]*model.Product, error) {
+func (p *productRepositoryImpl) SearchProducts(ctx context.Context, storeId int64, keyword string) ([]*model.Product, error) {
 	var products []*model.Product
 	query := `
         SELECT p.* FROM product p
@@ -186,9 +188,9 @@ func (p *productRepositoryImpl) SearchProducts(storeId int64, keyword string) ([
         WHERE sp.store_id = ? AND (name LIKE ? OR abstract_name LIKE ?)
         ORDER BY p.id DESC
     `
-	err := p.reader.Select(&products, query, storeId, "%"+keyword+"%", "%"+p.extractAbstractKoreanName(keyword)+"%")
+	err := p.reader.SelectContext(ctx, &products, query, storeId, "%"+keyword+"%", "%"+extractAbstractKoreanName(keyword)+"%")
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		panic(err)
+		return nil, err
 	}
 	return products, nil
 }
@@ -1,11 +1,24 @@
 package repository
 
-import "store-management/internal/datasource"
+import (
+	"database/sql"
+	"store-management/internal/datasource"
+)
 
 type Repository interface {
 	UserRepository() UserRepository
 	StoreRepository() StoreRepository
 	ProductRepository() ProductRepository
+	SessionRepository() SessionRepository
+	APIKeyRepository() APIKeyRepository
+	MemberRepository() MemberRepository
+	VerificationRepository() VerificationRepository
+	AuthEventRepository() AuthEventRepository
+	LoginAttemptRepository() LoginAttemptRepository
+	TwoFactorRepository() TwoFactorRepository
+	// Transaction runs a unit of work spanning several repositories, which
+	// take part in it through the ctx passed to the TxFunc.
+	Transaction() datasource.Transaction
 }
 
 type repositoryImpl struct {
@@ -14,9 +27,16 @@ type repositoryImpl struct {
 	transaction datasource.Transaction
 	cache       datasource.Cache
 
-	user    UserRepository
-	store   StoreRepository
-	product ProductRepository
+	user         UserRepository
+	store        StoreRepository
+	product      ProductRepository
+	session      SessionRepository
+	apiKey       APIKeyRepository
+	member       MemberRepository
+	verification VerificationRepository
+	authEvent    AuthEventRepository
+	loginAttempt LoginAttemptRepository
+	twoFactor    TwoFactorRepository
 }
 
 func (r *repositoryImpl) UserRepository() UserRepository {
@@ -31,25 +51,88 @@ func (r *repositoryImpl) ProductRepository() ProductRepository {
 	return r.product
 }
 
+func (r *repositoryImpl) SessionRepository() SessionRepository {
+	return r.session
+}
+
+func (r *repositoryImpl) APIKeyRepository() APIKeyRepository {
+	return r.apiKey
+}
+
+func (r *repositoryImpl) MemberRepository() MemberRepository {
+	return r.member
+}
+
+func (r *repositoryImpl) VerificationRepository() VerificationRepository {
+	return r.verification
+}
+
+func (r *repositoryImpl) AuthEventRepository() AuthEventRepository {
+	return r.authEvent
+}
+
+func (r *repositoryImpl) LoginAttemptRepository() LoginAttemptRepository {
+	return r.loginAttempt
+}
+
+func (r *repositoryImpl) TwoFactorRepository() TwoFactorRepository {
+	return r.twoFactor
+}
+
+func (r *repositoryImpl) Transaction() datasource.Transaction {
+	return r.transaction
+}
+
 var repo Repository
 
 func Init(writer, reader datasource.SQL, transaction datasource.Transaction, cache datasource.Cache) {
 	if repo != nil {
 		return
 	}
+	repo = New(writer, reader, transaction, cache)
+}
 
-	repo = &repositoryImpl{
+// InitInMemory is Init without a database, for local development.
+func InitInMemory(cache datasource.Cache) {
+	if repo != nil {
+		return
+	}
+	repo = NewInMemory(cache)
+}
+
+func New(writer, reader datasource.SQL, transaction datasource.Transaction, cache datasource.Cache) Repository {
+	return &repositoryImpl{
 		writer:      writer,
 		reader:      reader,
 		transaction: transaction,
 		cache:       cache,
 
-		user:    NewUserRepository(writer, reader, cache),
-		store:   NewStoreRepository(writer, reader),
-		product: NewProductRepository(writer, reader, transaction),
+		user:         NewUserRepository(writer, reader, transaction, cache),
+		store:        NewStoreRepository(writer, reader, transaction),
+		product:      NewProductRepository(writer, reader, transaction),
+		session:      NewSessionRepository(writer, reader, transaction),
+		apiKey:       NewAPIKeyRepository(writer, reader),
+		member:       NewMemberRepository(writer, reader, transaction),
+		verification: NewVerificationRepository(cache),
+		authEvent:    NewAuthEventRepository(writer, reader),
+		loginAttempt: NewLoginAttemptRepository(cache),
+		twoFactor:    NewTwoFactorRepository(writer, reader, transaction, cache),
 	}
 }
 
 func Get() Repository {
 	return repo
 }
+
+// expectAffected returns datasource.ErrNoRows when res changed no row, which
+// also rolls back a transaction it is returned in.
+func expectAffected(res sql.Result) error {
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
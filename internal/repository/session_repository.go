@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *model.Session, device *model.DeviceSession) error
+	FindSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	RotateSession(ctx context.Context, sessionId int64, next *model.Session) error
+	RevokeSessionFamily(ctx context.Context, familyId string) error
+	RevokeUserSessions(ctx context.Context, userId int64) error
+	RevokeOtherSessions(ctx context.Context, userId int64, keepFamilyId string) error
+	FindDeviceSession(ctx context.Context, familyId string) (*model.DeviceSession, error)
+	FindDeviceSessionByID(ctx context.Context, userId, id int64) (*model.DeviceSession, error)
+	FindDeviceSessionsByUserID(ctx context.Context, userId int64) ([]*model.DeviceSession, error)
+	TouchDeviceSession(ctx context.Context, familyId string, seenAt time.Time, ip string) error
+}
+
+type sessionRepositoryImpl struct {
+	writer      datasource.SQL
+	reader      datasource.SQL
+	transaction datasource.Transaction
+}
+
+func NewSessionRepository(writer, reader datasource.SQL, transaction datasource.Transaction) SessionRepository {
+	return &sessionRepositoryImpl{
+		writer:      writer,
+		reader:      reader,
+		transaction: transaction,
+	}
+}
+
+// CreateSession starts a refresh token family with its first session and the
+// device it was started on.
+func (s *sessionRepositoryImpl) CreateSession(ctx context.Context, session *model.Session, device *model.DeviceSession) error {
+	return s.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		res, err := tx.ExecContext(ctx, "INSERT INTO device_session (family_id, user_id, device_label, user_agent, ip, last_seen_at) VALUES (?, ?, ?, ?, ?, NOW())",
+			session.FamilyID, session.UserID, device.DeviceLabel, device.UserAgent, device.IP)
+		if err != nil {
+			return err
+		}
+		if device.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+
+		res, err = tx.ExecContext(ctx, "INSERT INTO session (family_id, user_id, token_hash, expires_at) VALUES (?, ?, ?, ?)",
+			session.FamilyID, session.UserID, session.TokenHash, session.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		session.ID, err = res.LastInsertId()
+		return err
+	})
+}
+
+func (s *sessionRepositoryImpl) FindSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	var session model.Session
+	// Read from the writer so that a rotation that just happened is never missed.
+	err := s.writer.GetContext(ctx, &session, "SELECT * FROM session WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateSession marks the given session as used and stores its successor in
+// the same transaction. It returns datasource.ErrNoRows when the session was
+// already rotated or revoked, which callers must treat as token reuse.
+func (s *sessionRepositoryImpl) RotateSession(ctx context.Context, sessionId int64, next *model.Session) error {
+	return s.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		res, err := tx.ExecContext(ctx, "UPDATE session SET rotated_at = NOW() WHERE id = ? AND rotated_at IS NULL AND revoked_at IS NULL", sessionId)
+		if err != nil {
+			return err
+		}
+		if err := expectAffected(res); err != nil {
+			return err
+		}
+
+		res, err = tx.ExecContext(ctx, "INSERT INTO session (family_id, user_id, token_hash, expires_at) VALUES (?, ?, ?, ?)",
+			next.FamilyID, next.UserID, next.TokenHash, next.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		next.ID, err = res.LastInsertId()
+		return err
+	})
+}
+
+func (s *sessionRepositoryImpl) RevokeSessionFamily(ctx context.Context, familyId string) error {
+	return s.revoke(ctx, "family_id = ?", familyId)
+}
+
+func (s *sessionRepositoryImpl) RevokeUserSessions(ctx context.Context, userId int64) error {
+	return s.revoke(ctx, "user_id = ?", userId)
+}
+
+func (s *sessionRepositoryImpl) RevokeOtherSessions(ctx context.Context, userId int64, keepFamilyId string) error {
+	return s.revoke(ctx, "user_id = ? AND family_id <> ?", userId, keepFamilyId)
+}
+
+// revoke revokes the refresh tokens and device sessions matching where, which
+// must only use columns both tables have.
+func (s *sessionRepositoryImpl) revoke(ctx context.Context, where string, args ...interface{}) error {
+	return s.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		if _, err := tx.ExecContext(ctx, "UPDATE session SET revoked_at = NOW() WHERE revoked_at IS NULL AND "+where, args...); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE device_session SET revoked_at = NOW() WHERE revoked_at IS NULL AND "+where, args...)
+		return err
+	})
+}
+
+// FindDeviceSession reads from the writer so that a revocation that just
+// happened is never missed.
+func (s *sessionRepositoryImpl) FindDeviceSession(ctx context.Context, familyId string) (*model.DeviceSession, error) {
+	var device model.DeviceSession
+	err := s.writer.GetContext(ctx, &device, "SELECT * FROM device_session WHERE family_id = ?", familyId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (s *sessionRepositoryImpl) FindDeviceSessionByID(ctx context.Context, userId, id int64) (*model.DeviceSession, error) {
+	var device model.DeviceSession
+	err := s.reader.GetContext(ctx, &device, "SELECT * FROM device_session WHERE id = ? AND user_id = ?", id, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &device, nil
+}
+
+// FindDeviceSessionsByUserID returns the sessions that can still be used: not
+// revoked and holding an unused refresh token that has not expired.
+func (s *sessionRepositoryImpl) FindDeviceSessionsByUserID(ctx context.Context, userId int64) ([]*model.DeviceSession, error) {
+	devices := make([]*model.DeviceSession, 0)
+	err := s.reader.SelectContext(ctx, &devices, `SELECT d.* FROM device_session d
+		WHERE d.user_id = ? AND d.revoked_at IS NULL AND EXISTS (
+			SELECT 1 FROM session s
+			WHERE s.family_id = d.family_id AND s.rotated_at IS NULL AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		)
+		ORDER BY d.last_seen_at DESC`, userId)
+	if err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (s *sessionRepositoryImpl) TouchDeviceSession(ctx context.Context, familyId string, seenAt time.Time, ip string) error {
+	_, err := s.writer.ExecContext(ctx, "UPDATE device_session SET last_seen_at = ?, ip = ? WHERE family_id = ?", seenAt, ip, familyId)
+	return err
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"store-management/internal/datasource"
@@ -8,39 +9,112 @@ import (
 )
 
 type StoreRepository interface {
-	CreateStore(userId int64) error
-	FindStoreByUserID(userId int64) (*model.Store, error)
+	CreateStore(ctx context.Context, store *model.Store) (int64, error)
+	FindStore(ctx context.Context, storeId int64) (*model.Store, error)
+	FindStoresByUserID(ctx context.Context, userId int64) ([]*model.Store, error)
+	UpdateStore(ctx context.Context, store *model.Store) error
+	DeleteStore(ctx context.Context, storeId int64) error
 }
 
 type storeRepositoryImpl struct {
-	writer datasource.SQL
-	reader datasource.SQL
+	writer      datasource.SQL
+	reader      datasource.SQL
+	transaction datasource.Transaction
 }
 
-func NewStoreRepository(writer, reader datasource.SQL) StoreRepository {
+func NewStoreRepository(writer, reader datasource.SQL, transaction datasource.Transaction) StoreRepository {
 	return &storeRepositoryImpl{
-		writer: writer,
-		reader: reader,
+		writer:      writer,
+		reader:      reader,
+		transaction: transaction,
 	}
 }
 
-func (s *storeRepositoryImpl) CreateStore(userId int64) error {
-	_, err := s.writer.Exec("INSERT INTO store (user_id) VALUES (?)", userId)
+// CreateStore creates a store and registers store.UserID as its owner member.
+func (s *storeRepositoryImpl) CreateStore(ctx context.Context, store *model.Store) (int64, error) {
+	var storeId int64
+	err := s.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		res, err := tx.ExecContext(ctx, "INSERT INTO store (user_id, name, address, phone, business_hours, timezone) VALUES (?, ?, ?, ?, ?, ?)",
+			store.UserID, store.Name, store.Address, store.Phone, store.BusinessHours, store.Timezone)
+		if err != nil {
+			return err
+		}
+		if storeId, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO store_member (store_id, user_id, role) VALUES (?, ?, ?)", storeId, store.UserID, model.RoleOwner)
+		return err
+	})
 	if err != nil {
-		panic(err)
+		return 0, err
 	}
-	return nil
+	return storeId, nil
 }
 
-func (s *storeRepositoryImpl) FindStoreByUserID(userId int64) (*model.Store, error) {
+func (s *storeRepositoryImpl) FindStore(ctx context.Context, storeId int64) (*model.Store, error) {
 	var store model.Store
-	err := s.reader.Get(&store, "SELECT id, user_id FROM store WHERE user_id = ?", userId)
+	err := s.reader.GetContext(ctx, &store, "SELECT * FROM store WHERE id = ?", storeId)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datasource.ErrNoRows
 		}
-		panic(err)
+		return nil, err
 	}
 	return &store, nil
 }
+
+// FindStoresByUserID lists every store the user is a member of, together with
+// the user's role there.
+func (s *storeRepositoryImpl) FindStoresByUserID(ctx context.Context, userId int64) ([]*model.Store, error) {
+	stores := []*model.Store{}
+	err := s.reader.SelectContext(ctx, &stores, `
+        SELECT s.*, sm.role FROM store s
+        INNER JOIN store_member sm ON s.id = sm.store_id
+        WHERE sm.user_id = ?
+        ORDER BY s.id
+    `, userId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return stores, nil
+}
+
+func (s *storeRepositoryImpl) UpdateStore(ctx context.Context, store *model.Store) error {
+	res, err := s.writer.ExecContext(ctx, "UPDATE store SET name = ?, address = ?, phone = ?, business_hours = ?, timezone = ?, updated_at = NOW() WHERE id = ?",
+		store.Name, store.Address, store.Phone, store.BusinessHours, store.Timezone, store.ID)
+	if err != nil {
+		return err
+	}
+	if affectedRows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteStore removes the store together with its products, members,
+// invitations and API keys.
+func (s *storeRepositoryImpl) DeleteStore(ctx context.Context, storeId int64) error {
+	return s.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		queries := []string{
+			"DELETE p FROM product p INNER JOIN store_product sp ON p.id = sp.product_id WHERE sp.store_id = ?",
+			"DELETE FROM store_product WHERE store_id = ?",
+			"DELETE FROM store_member WHERE store_id = ?",
+			"DELETE FROM store_invitation WHERE store_id = ?",
+			"DELETE FROM api_key WHERE store_id = ?",
+		}
+		for _, query := range queries {
+			if _, err := tx.ExecContext(ctx, query, storeId); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, "DELETE FROM store WHERE id = ?", storeId)
+		if err != nil {
+			return err
+		}
+		return expectAffected(res)
+	})
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type TwoFactorRepository interface {
+	FindTOTP(ctx context.Context, userId int64) (*model.TOTP, error)
+	SaveTOTP(ctx context.Context, totp *model.TOTP) error
+	EnableTOTP(ctx context.Context, userId int64, recoveryCodeHashes []string) error
+	DeleteTOTP(ctx context.Context, userId int64) error
+	UseTOTPStep(ctx context.Context, userId int64, step int64) error
+	UseRecoveryCode(ctx context.Context, userId int64, codeHash string) error
+	SaveLoginChallenge(ctx context.Context, tokenHash string, challenge *model.LoginChallenge) error
+	FindLoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error)
+	DeleteLoginChallenge(ctx context.Context, tokenHash string)
+}
+
+// twoFactorRepositoryImpl keeps enrollments in the database and pending login
+// challenges, which only live for minutes, in the cache.
+type twoFactorRepositoryImpl struct {
+	writer      datasource.SQL
+	reader      datasource.SQL
+	transaction datasource.Transaction
+	cache       datasource.Cache
+}
+
+func NewTwoFactorRepository(writer, reader datasource.SQL, transaction datasource.Transaction, cache datasource.Cache) TwoFactorRepository {
+	return &twoFactorRepositoryImpl{
+		writer:      writer,
+		reader:      reader,
+		transaction: transaction,
+		cache:       cache,
+	}
+}
+
+// FindTOTP reads from the writer so that the replay check in UseTOTPStep and
+// the enrollment state are always current.
+func (t *twoFactorRepositoryImpl) FindTOTP(ctx context.Context, userId int64) (*model.TOTP, error) {
+	var totp model.TOTP
+	err := t.writer.GetContext(ctx, &totp, "SELECT * FROM user_totp WHERE user_id = ?", userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datasource.ErrNoRows
+		}
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// SaveTOTP stores a pending enrollment, replacing a previous pending one.
+func (t *twoFactorRepositoryImpl) SaveTOTP(ctx context.Context, totp *model.TOTP) error {
+	_, err := t.writer.ExecContext(ctx, `INSERT INTO user_totp (user_id, secret) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), last_used_step = 0, enabled_at = NULL`,
+		totp.UserID, totp.Secret)
+	return err
+}
+
+// EnableTOTP enables the pending enrollment and replaces the recovery codes of
+// the user in one transaction.
+func (t *twoFactorRepositoryImpl) EnableTOTP(ctx context.Context, userId int64, recoveryCodeHashes []string) error {
+	return t.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		res, err := tx.ExecContext(ctx, "UPDATE user_totp SET enabled_at = NOW() WHERE user_id = ? AND enabled_at IS NULL", userId)
+		if err != nil {
+			return err
+		}
+		if err := expectAffected(res); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM user_recovery_code WHERE user_id = ?", userId); err != nil {
+			return err
+		}
+		for _, codeHash := range recoveryCodeHashes {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO user_recovery_code (user_id, code_hash) VALUES (?, ?)", userId, codeHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (t *twoFactorRepositoryImpl) DeleteTOTP(ctx context.Context, userId int64) error {
+	return t.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM user_recovery_code WHERE user_id = ?", userId); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id = ?", userId)
+		return err
+	})
+}
+
+// UseTOTPStep records that the code of step was used. It returns
+// datasource.ErrNoRows when that step or a later one was already used, so a
+// code can never be accepted twice.
+func (t *twoFactorRepositoryImpl) UseTOTPStep(ctx context.Context, userId int64, step int64) error {
+	res, err := t.writer.ExecContext(ctx, "UPDATE user_totp SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?", step, userId, step)
+	if err != nil {
+		return err
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used, or returns
+// datasource.ErrNoRows when there is none.
+func (t *twoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) error {
+	res, err := t.writer.ExecContext(ctx, "UPDATE user_recovery_code SET used_at = NOW() WHERE user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash)
+	if err != nil {
+		return err
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affectedRows == 0 {
+		return datasource.ErrNoRows
+	}
+	return nil
+}
+
+func loginChallengeKey(tokenHash string) string {
+	return fmt.Sprintf("login_challenge:%s", tokenHash)
+}
+
+func (t *twoFactorRepositoryImpl) SaveLoginChallenge(ctx context.Context, tokenHash string, challenge *model.LoginChallenge) error {
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return t.cache.Set(loginChallengeKey(tokenHash), *challenge, ttl)
+}
+
+func (t *twoFactorRepositoryImpl) FindLoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	value, err := t.cache.Get(loginChallengeKey(tokenHash))
+	if err != nil {
+		return nil, err
+	}
+	challenge, ok := value.(model.LoginChallenge)
+	if !ok {
+		return nil, datasource.ErrNoRows
+	}
+	return &challenge, nil
+}
+
+func (t *twoFactorRepositoryImpl) DeleteLoginChallenge(ctx context.Context, tokenHash string) {
+	t.cache.Invalidate(loginChallengeKey(tokenHash))
+}
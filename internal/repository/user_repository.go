@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"store-management/internal/datasource"
@@ -11,28 +12,34 @@ import (
 )
 
 type UserRepository interface {
-	CreateUser(phoneNumber, password string) error
-	FindUser(phoneNumber string) (*model.User, error)
-	FindUserByID(id int64) (*model.User, error)
-	BlockAuthToken(string) error
+	CreateUser(ctx context.Context, phoneNumber, password string) error
+	FindUser(ctx context.Context, phoneNumber string) (*model.User, error)
+	FindUserByID(ctx context.Context, id int64) (*model.User, error)
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	InvalidateAuthTokens(ctx context.Context, id int64, issuedBefore time.Time) error
+	BlockAuthToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAuthTokenBlocked(ctx context.Context, jti string) (bool, error)
+	DeleteUser(ctx context.Context, user *model.User) error
 }
 
 type userRepositoryImpl struct {
-	writer datasource.SQL
-	reader datasource.SQL
-	cache  datasource.Cache
+	writer      datasource.SQL
+	reader      datasource.SQL
+	transaction datasource.Transaction
+	cache       datasource.Cache
 }
 
-func NewUserRepository(writer, reader datasource.SQL, cache datasource.Cache) UserRepository {
+func NewUserRepository(writer, reader datasource.SQL, transaction datasource.Transaction, cache datasource.Cache) UserRepository {
 	return &userRepositoryImpl{
-		writer: writer,
-		reader: reader,
-		cache:  cache,
+		writer:      writer,
+		reader:      reader,
+		transaction: transaction,
+		cache:       cache,
 	}
 }
 
-func (u *userRepositoryImpl) CreateUser(phoneNumber, password string) error {
-	_, err := u.writer.Exec("INSERT INTO user (phone_number, password) VALUES (?, ?)", phoneNumber, password)
+func (u *userRepositoryImpl) CreateUser(ctx context.Context, phoneNumber, password string) error {
+	_, err := u.writer.ExecContext(ctx, "INSERT INTO user (phone_number, password) VALUES (?, ?)", phoneNumber, password)
 	if err != nil {
 		var mysqlError *mysql.MySQLError
 		if errors.As(err, &mysqlError) {
@@ -40,38 +47,102 @@ func (u *userRepositoryImpl) CreateUser(phoneNumber, password string) error {
 				return datasource.ErrDuplicateEntry
 			}
 		}
-		panic(err)
+		return err
 	}
 	return nil
 }
 
-func (u *userRepositoryImpl) FindUser(phoneNumber string) (*model.User, error) {
+func (u *userRepositoryImpl) FindUser(ctx context.Context, phoneNumber string) (*model.User, error) {
 	var user model.User
-	err := u.reader.Get(&user, "SELECT id, phone_number, password FROM user WHERE phone_number = ?", phoneNumber)
+	err := u.reader.GetContext(ctx, &user, "SELECT id, phone_number, password, tokens_valid_after FROM user WHERE phone_number = ?", phoneNumber)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datasource.ErrNoRows
 		}
-		panic(err)
+		return nil, err
 	}
 	return &user, err
 }
 
-func (u *userRepositoryImpl) FindUserByID(id int64) (*model.User, error) {
+func (u *userRepositoryImpl) FindUserByID(ctx context.Context, id int64) (*model.User, error) {
 	var user model.User
-	err := u.reader.Get(&user, "SELECT id, phone_number, password FROM user WHERE id = ?", id)
+	err := u.reader.GetContext(ctx, &user, "SELECT id, phone_number, password, tokens_valid_after FROM user WHERE id = ?", id)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datasource.ErrNoRows
 		}
-		panic(err)
+		return nil, err
 	}
 	return &user, err
 }
 
-func (u *userRepositoryImpl) BlockAuthToken(authToken string) error {
-	_ = u.cache.Set(authToken, struct{}{}, time.Hour)
-	return nil
+func (u *userRepositoryImpl) UpdatePassword(ctx context.Context, id int64, password string) error {
+	_, err := u.writer.ExecContext(ctx, "UPDATE user SET password = ?, updated_at = NOW() WHERE id = ?", password, id)
+	return err
+}
+
+// InvalidateAuthTokens makes every access token of the user issued before
+// issuedBefore invalid. The time is truncated to whole seconds like the iat
+// claim it is compared with.
+func (u *userRepositoryImpl) InvalidateAuthTokens(ctx context.Context, id int64, issuedBefore time.Time) error {
+	_, err := u.writer.ExecContext(ctx, "UPDATE user SET tokens_valid_after = ?, updated_at = NOW() WHERE id = ?", issuedBefore.Truncate(time.Second), id)
+	return err
+}
+
+const authTokenDenylistKeyPrefix = "auth_token:denylist:"
+
+func (u *userRepositoryImpl) BlockAuthToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return u.cache.Set(authTokenDenylistKeyPrefix+jti, struct{}{}, ttl)
+}
+
+func (u *userRepositoryImpl) IsAuthTokenBlocked(ctx context.Context, jti string) (bool, error) {
+	value, err := u.cache.Get(authTokenDenylistKeyPrefix + jti)
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+// DeleteUser removes the user with everything tied to them in one
+// transaction: the stores they created with their products, members,
+// invitations and API keys, their memberships and pending invitations
+// elsewhere, sessions and two-factor settings. Auth events are kept for
+// security auditing with the phone number erased.
+func (u *userRepositoryImpl) DeleteUser(ctx context.Context, user *model.User) error {
+	return u.transaction.WithinTx(ctx, func(ctx context.Context, tx datasource.TxExecer) error {
+		queries := []string{
+			"DELETE p FROM product p INNER JOIN store_product sp ON p.id = sp.product_id INNER JOIN store s ON s.id = sp.store_id WHERE s.user_id = ?",
+			"DELETE sp FROM store_product sp INNER JOIN store s ON s.id = sp.store_id WHERE s.user_id = ?",
+			"DELETE sm FROM store_member sm INNER JOIN store s ON s.id = sm.store_id WHERE s.user_id = ?",
+			"DELETE si FROM store_invitation si INNER JOIN store s ON s.id = si.store_id WHERE s.user_id = ?",
+			"DELETE ak FROM api_key ak INNER JOIN store s ON s.id = ak.store_id WHERE s.user_id = ?",
+			"DELETE FROM store WHERE user_id = ?",
+			"DELETE FROM store_member WHERE user_id = ?",
+			"DELETE FROM session WHERE user_id = ?",
+			"DELETE FROM device_session WHERE user_id = ?",
+			"DELETE FROM user_recovery_code WHERE user_id = ?",
+			"DELETE FROM user_totp WHERE user_id = ?",
+			"UPDATE auth_event SET phone_number = '' WHERE user_id = ?",
+		}
+		for _, query := range queries {
+			if _, err := tx.ExecContext(ctx, query, user.ID); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM store_invitation WHERE phone_number = ? AND accepted_at IS NULL", user.PhoneNumber); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, "DELETE FROM user WHERE id = ?", user.ID)
+		if err != nil {
+			return err
+		}
+		return expectAffected(res)
+	})
 }
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"time"
+)
+
+type VerificationRepository interface {
+	SaveCode(ctx context.Context, code *model.VerificationCode) error
+	FindCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string) (*model.VerificationCode, error)
+	DeleteCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string)
+	IncreaseCodeAttempts(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string) (*model.VerificationCode, error)
+	IncreaseSendCount(ctx context.Context, phoneNumber string, window time.Duration) (int, error)
+	SaveVerifiedToken(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, tokenHash string, ttl time.Duration) error
+	ConsumeVerifiedToken(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, tokenHash string) (bool, error)
+}
+
+// verificationRepositoryImpl keeps short-lived verification state in the
+// cache only; none of it needs to survive a restart.
+type verificationRepositoryImpl struct {
+	cache datasource.Cache
+}
+
+func NewVerificationRepository(cache datasource.Cache) VerificationRepository {
+	return &verificationRepositoryImpl{
+		cache: cache,
+	}
+}
+
+type sendCounter struct {
+	count   int
+	resetAt time.Time
+}
+
+func verificationCodeKey(purpose model.VerificationPurpose, phoneNumber string) string {
+	return fmt.Sprintf("verification:code:%s:%s", purpose, phoneNumber)
+}
+
+func verificationSendCountKey(phoneNumber string) string {
+	return fmt.Sprintf("verification:send_count:%s", phoneNumber)
+}
+
+func verifiedTokenKey(purpose model.VerificationPurpose, phoneNumber string) string {
+	return fmt.Sprintf("verification:verified:%s:%s", purpose, phoneNumber)
+}
+
+func (v *verificationRepositoryImpl) SaveCode(ctx context.Context, code *model.VerificationCode) error {
+	ttl := time.Until(code.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	saved := *code
+	return v.cache.Set(verificationCodeKey(code.Purpose, code.PhoneNumber), saved, ttl)
+}
+
+func (v *verificationRepositoryImpl) FindCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string) (*model.VerificationCode, error) {
+	value, err := v.cache.Get(verificationCodeKey(purpose, phoneNumber))
+	if err != nil {
+		return nil, err
+	}
+	code, ok := value.(model.VerificationCode)
+	if !ok {
+		return nil, datasource.ErrNoRows
+	}
+	return &code, nil
+}
+
+func (v *verificationRepositoryImpl) DeleteCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string) {
+	v.cache.Invalidate(verificationCodeKey(purpose, phoneNumber))
+}
+
+// IncreaseCodeAttempts atomically counts an attempt at the code sent to
+// phoneNumber and returns the code with the count including this attempt.
+func (v *verificationRepositoryImpl) IncreaseCodeAttempts(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string) (*model.VerificationCode, error) {
+	var code model.VerificationCode
+	err := v.cache.Update(verificationCodeKey(purpose, phoneNumber), func(value interface{}) (interface{}, time.Duration, error) {
+		stored, ok := value.(model.VerificationCode)
+		ttl := time.Until(stored.ExpiresAt)
+		if !ok || ttl <= 0 {
+			return nil, 0, datasource.ErrNoRows
+		}
+		stored.Attempts++
+		code = stored
+		return stored, ttl, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// IncreaseSendCount atomically counts a send to phoneNumber in a fixed window
+// starting at the first send, and returns the count including this one.
+func (v *verificationRepositoryImpl) IncreaseSendCount(ctx context.Context, phoneNumber string, window time.Duration) (int, error) {
+	var count int
+	err := v.cache.Update(verificationSendCountKey(phoneNumber), func(value interface{}) (interface{}, time.Duration, error) {
+		now := time.Now()
+		counter, ok := value.(sendCounter)
+		if !ok || !counter.resetAt.After(now) {
+			counter = sendCounter{resetAt: now.Add(window)}
+		}
+		counter.count++
+		count = counter.count
+		return counter, counter.resetAt.Sub(now), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (v *verificationRepositoryImpl) SaveVerifiedToken(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, tokenHash string, ttl time.Duration) error {
+	return v.cache.Set(verifiedTokenKey(purpose, phoneNumber), tokenHash, ttl)
+}
+
+// errVerifiedTokenMismatch leaves a verified token that does not match in
+// place.
+var errVerifiedTokenMismatch = errors.New("verified token mismatch")
+
+// ConsumeVerifiedToken deletes the verified token of phoneNumber and reports
+// whether it matched tokenHash. The check and the delete are one atomic step,
+// so concurrent requests cannot both consume the same token.
+func (v *verificationRepositoryImpl) ConsumeVerifiedToken(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, tokenHash string) (bool, error) {
+	err := v.cache.Update(verifiedTokenKey(purpose, phoneNumber), func(value interface{}) (interface{}, time.Duration, error) {
+		if stored, ok := value.(string); !ok || stored != tokenHash {
+			return nil, 0, errVerifiedTokenMismatch
+		}
+		// Storing it without a ttl expires it right away.
+		return nil, 0, nil
+	})
+	if errors.Is(err, errVerifiedTokenMismatch) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -1,16 +1,28 @@
 package response
 
+import "github.com/gin-gonic/gin"
+
+// RequestIDKey is the gin context key of the request ID.
+const RequestIDKey = "requestID"
+
 const (
-	MessageOK            = "ok"
-	MessageInvalidInput  = "invalid input"
-	MessageInternalError = "internal server error"
-	MessageUnauthorized  = "unauthorized"
-	MessageNotFound      = "not found"
+	MessageOK              = "ok"
+	MessageInvalidInput    = "invalid input"
+	MessageInternalError   = "internal server error"
+	MessageUnauthorized    = "unauthorized"
+	MessageForbidden       = "forbidden"
+	MessageNotFound        = "not found"
+	MessageConflict        = "conflict"
+	MessageTooManyRequests = "too many requests"
+	MessageUnavailable     = "service unavailable"
 )
 
 type Meta struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// RequestID is the X-Request-ID of the request, so clients can quote it
+	// when reporting a problem.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type Response struct {
@@ -27,3 +39,23 @@ func New(code int, message string, data interface{}) Response {
 		Data: data,
 	}
 }
+
+// Context is the part of a gin.Context JSON writes with, also provided by the
+// contexts embedding one.
+type Context interface {
+	GetString(key string) string
+	JSON(code int, obj any)
+}
+
+// JSON writes res with the request ID in its meta.
+func JSON(c Context, code int, res Response) {
+	res.Meta.RequestID = c.GetString(RequestIDKey)
+	c.JSON(code, res)
+}
+
+// AbortJSON writes res with the request ID in its meta and stops the
+// handler chain.
+func AbortJSON(c *gin.Context, code int, res Response) {
+	res.Meta.RequestID = c.GetString(RequestIDKey)
+	c.AbortWithStatusJSON(code, res)
+}
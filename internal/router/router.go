@@ -3,6 +3,7 @@ package router
 import (
 	"net/http"
 	"store-management/internal/controller"
+	"store-management/internal/middleware"
 	"store-management/internal/model"
 	"store-management/internal/response"
 	"store-management/internal/service"
@@ -10,33 +11,133 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRequiredHandler(handler func(ctx *controller.AuthContext)) func(c *gin.Context) {
+// AuthRequiredHandler rejects anonymous requests. API keys are only accepted
+// on routes that declare scopes, and must hold every one of them; routes
+// without scopes are reserved for logged-in users.
+func AuthRequiredHandler(handler func(ctx *controller.AuthContext), scopes ...string) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		if apiKey, ok := c.Value(middleware.APIKeyContextKey).(*model.APIKey); ok {
+			if len(scopes) == 0 || !apiKey.HasScopes(scopes...) {
+				response.JSON(c, http.StatusForbidden, response.New(http.StatusForbidden, response.MessageForbidden, nil))
+				return
+			}
+
+			authContext := controller.AuthContext{Context: c, APIKey: apiKey}
+			handler(&authContext)
+			c.Next()
+			return
+		}
+
 		user := c.Value("user")
 		if user == nil {
-			c.JSON(http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
+			response.JSON(c, http.StatusUnauthorized, response.New(http.StatusUnauthorized, response.MessageUnauthorized, nil))
 			return
 		}
 
-		authContext := controller.AuthContext{Context: c, User: user.(*model.User)}
+		authContext := controller.AuthContext{Context: c, User: user.(*model.User), SessionID: c.GetString(middleware.SessionIDKey)}
 		handler(&authContext)
 		c.Next()
 	}
 }
 
+// CSRFExemptPaths are the routes that work without being logged in. A
+// browser may still send an auth cookie to them, whose CSRF cookie may
+// already have expired, so middleware.CSRFMiddleware must not check them.
+// /auth/refresh issues a new CSRF cookie along with the new tokens.
+var CSRFExemptPaths = []string{
+	"/v1/auth/phone/send-code",
+	"/v1/auth/phone/verify",
+	"/v1/auth/register",
+	"/v1/auth/login",
+	"/v1/auth/login/2fa",
+	"/v1/auth/refresh",
+	"/v1/auth/password/reset/send-code",
+	"/v1/auth/password/reset/verify",
+	"/v1/auth/password/reset",
+}
+
 func Init(router *gin.Engine, srv *service.Service) {
-	authController := controller.NewAuthController(srv.AuthService)
+	authController := controller.NewAuthController(srv.AuthService, srv.VerificationService, srv.TwoFactorService)
 	productController := controller.NewProductController(srv.StoreService)
+	apiKeyController := controller.NewAPIKeyController(srv.StoreService, srv.APIKeyService)
+	memberController := controller.NewMemberController(srv.StoreService, srv.MemberService)
+	storeController := controller.NewStoreController(srv.StoreService)
+	sessionController := controller.NewSessionController(srv.SessionService)
+	twoFactorController := controller.NewTwoFactorController(srv.TwoFactorService)
+	accountController := controller.NewAccountController(srv.AccountService)
+
+	router.GET("/.well-known/jwks.json", authController.JWKS)
 
 	v1 := router.Group("/v1")
+	v1.POST("/auth/phone/send-code", authController.SendPhoneCode)
+	v1.POST("/auth/phone/verify", authController.VerifyPhoneCode)
 	v1.POST("/auth/register", authController.Register)
 	v1.POST("/auth/login", authController.Login)
+	v1.POST("/auth/login/2fa", authController.LoginTwoFactor)
 	v1.POST("/auth/logout", authController.Logout)
+	v1.POST("/auth/refresh", authController.Refresh)
+	v1.GET("/auth/2fa", AuthRequiredHandler(twoFactorController.Status))
+	v1.POST("/auth/2fa/totp", AuthRequiredHandler(twoFactorController.Enroll))
+	v1.POST("/auth/2fa/totp/confirm", AuthRequiredHandler(twoFactorController.Confirm))
+	v1.POST("/auth/2fa/totp/disable", AuthRequiredHandler(twoFactorController.Disable))
+	v1.GET("/auth/sessions", AuthRequiredHandler(sessionController.List))
+	v1.DELETE("/auth/sessions/:id", AuthRequiredHandler(sessionController.Delete))
+	v1.POST("/auth/password/change", AuthRequiredHandler(authController.ChangePassword))
+	v1.POST("/auth/password/reset/send-code", authController.SendPasswordResetCode)
+	v1.POST("/auth/password/reset/verify", authController.VerifyPasswordResetCode)
+	v1.POST("/auth/password/reset", authController.ResetPassword)
+
+	v1.GET("/me/export", AuthRequiredHandler(accountController.Export))
+	v1.DELETE("/me", AuthRequiredHandler(accountController.Delete))
+
+	v1.GET("/stores", AuthRequiredHandler(storeController.List))
+	v1.POST("/stores", AuthRequiredHandler(storeController.Create))
+
+	// Routes under /stores/:storeId act on the given store. The legacy routes
+	// below act on the store implied by the principal, see
+	// service.StoreService.ResolveAccess.
+	store := v1.Group("/stores/:storeId")
+	store.GET("", AuthRequiredHandler(storeController.Get))
+	store.PATCH("", AuthRequiredHandler(storeController.Update))
+	store.DELETE("", AuthRequiredHandler(storeController.Delete))
+
+	store.GET("/products", AuthRequiredHandler(productController.List, model.ScopeProductsRead))
+	store.GET("/products/search", AuthRequiredHandler(productController.Search, model.ScopeProductsRead))
+	store.GET("/products/:id", AuthRequiredHandler(productController.Get, model.ScopeProductsRead))
+	store.POST("/products", AuthRequiredHandler(productController.Create, model.ScopeProductsWrite))
+	store.DELETE("/products/:id", AuthRequiredHandler(productController.Delete, model.ScopeProductsWrite))
+	store.PATCH("/products/:id", AuthRequiredHandler(productController.Update, model.ScopeProductsWrite))
+
+	store.GET("/api-keys", AuthRequiredHandler(apiKeyController.List))
+	store.POST("/api-keys", AuthRequiredHandler(apiKeyController.Create))
+	store.GET("/api-keys/:id", AuthRequiredHandler(apiKeyController.Get))
+	store.PATCH("/api-keys/:id", AuthRequiredHandler(apiKeyController.Update))
+	store.DELETE("/api-keys/:id", AuthRequiredHandler(apiKeyController.Delete))
+
+	store.GET("/members", AuthRequiredHandler(memberController.List))
+	store.DELETE("/members/:userId", AuthRequiredHandler(memberController.Remove))
+	store.GET("/invitations", AuthRequiredHandler(memberController.ListInvitations))
+	store.POST("/invitations", AuthRequiredHandler(memberController.Invite))
+	store.DELETE("/invitations/:id", AuthRequiredHandler(memberController.CancelInvitation))
+
+	v1.GET("/product/:id", AuthRequiredHandler(productController.Get, model.ScopeProductsRead))
+	v1.GET("/products", AuthRequiredHandler(productController.List, model.ScopeProductsRead))
+	v1.GET("/products/search", AuthRequiredHandler(productController.Search, model.ScopeProductsRead))
+	v1.POST("/product", AuthRequiredHandler(productController.Create, model.ScopeProductsWrite))
+	v1.DELETE("/product/:id", AuthRequiredHandler(productController.Delete, model.ScopeProductsWrite))
+	v1.PATCH("/product/:id", AuthRequiredHandler(productController.Update, model.ScopeProductsWrite))
+
+	v1.GET("/api-keys", AuthRequiredHandler(apiKeyController.List))
+	v1.POST("/api-keys", AuthRequiredHandler(apiKeyController.Create))
+	v1.GET("/api-keys/:id", AuthRequiredHandler(apiKeyController.Get))
+	v1.PATCH("/api-keys/:id", AuthRequiredHandler(apiKeyController.Update))
+	v1.DELETE("/api-keys/:id", AuthRequiredHandler(apiKeyController.Delete))
 
-	v1.GET("/product/:id", AuthRequiredHandler(productController.Get))
-	v1.GET("/products", AuthRequiredHandler(productController.List))
-	v1.GET("/products/search", AuthRequiredHandler(productController.Search))
-	v1.POST("/product", AuthRequiredHandler(productController.Create))
-	v1.DELETE("/product/:id", AuthRequiredHandler(productController.Delete))
-	v1.PATCH("/product/:id", AuthRequiredHandler(productController.Update))
+	v1.GET("/store/members", AuthRequiredHandler(memberController.List))
+	v1.DELETE("/store/members/:userId", AuthRequiredHandler(memberController.Remove))
+	v1.GET("/store/invitations", AuthRequiredHandler(memberController.ListInvitations))
+	v1.POST("/store/invitations", AuthRequiredHandler(memberController.Invite))
+	v1.DELETE("/store/invitations/:id", AuthRequiredHandler(memberController.CancelInvitation))
+	v1.GET("/invitations", AuthRequiredHandler(memberController.ListMyInvitations))
+	v1.POST("/invitations/:id/accept", AuthRequiredHandler(memberController.AcceptInvitation))
 }
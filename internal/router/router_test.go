@@ -0,0 +1,98 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"store-management/internal/controller"
+	"store-management/internal/middleware"
+	"store-management/internal/model"
+	"store-management/internal/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newScopedRouter(principal func(c *gin.Context)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(principal)
+	ok := func(ctx *controller.AuthContext) {
+		ctx.Status(http.StatusOK)
+	}
+	r.GET("/read", AuthRequiredHandler(ok, model.ScopeProductsRead))
+	r.POST("/write", AuthRequiredHandler(ok, model.ScopeProductsWrite))
+	r.GET("/users-only", AuthRequiredHandler(ok))
+	return r
+}
+
+func serve(r *gin.Engine, method, path string) int {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec.Code
+}
+
+func TestAuthRequiredHandler_APIKeyScopes(t *testing.T) {
+	r := newScopedRouter(func(c *gin.Context) {
+		c.Set(middleware.APIKeyContextKey, &model.APIKey{ID: 1, StoreID: 1, Scopes: model.Scopes{model.ScopeProductsRead}})
+	})
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/read"))
+	assert.Equal(t, http.StatusForbidden, serve(r, http.MethodPost, "/write"))
+	assert.Equal(t, http.StatusForbidden, serve(r, http.MethodGet, "/users-only"))
+}
+
+func TestAuthRequiredHandler_User(t *testing.T) {
+	r := newScopedRouter(func(c *gin.Context) {
+		c.Set("user", &model.User{ID: 1})
+	})
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/read"))
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodPost, "/write"))
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/users-only"))
+}
+
+func TestAuthRequiredHandler_Anonymous(t *testing.T) {
+	r := newScopedRouter(func(c *gin.Context) {})
+
+	assert.Equal(t, http.StatusUnauthorized, serve(r, http.MethodGet, "/read"))
+}
+
+func TestInit_RegistersStoreScopedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Init(r, &service.Service{})
+
+	routes := map[string]bool{}
+	for _, route := range r.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	assert.True(t, routes["GET /v1/stores/:storeId/products/search"])
+	assert.True(t, routes["GET /v1/stores/:storeId/products/:id"])
+	assert.True(t, routes["PATCH /v1/stores/:storeId"])
+	assert.True(t, routes["GET /v1/product/:id"])
+}
+
+func TestCSRFExemptPaths_ExpiredCSRFCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// A valid auth cookie whose CSRF cookie has expired.
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.AuthMethodKey, middleware.AuthMethodCookie)
+	})
+	r.Use(middleware.CSRFMiddleware(CSRFExemptPaths...))
+	Init(r, &service.Service{})
+
+	for _, path := range []string{"/v1/auth/login", "/v1/auth/register", "/v1/auth/refresh"} {
+		assert.NotEqual(t, http.StatusForbidden, serve(r, http.MethodPost, path), path)
+	}
+	assert.Equal(t, http.StatusForbidden, serve(r, http.MethodPost, "/v1/auth/logout"))
+
+	routes := map[string]bool{}
+	for _, route := range r.Routes() {
+		routes[route.Path] = true
+	}
+	for _, path := range CSRFExemptPaths {
+		assert.True(t, routes[path], path)
+	}
+}
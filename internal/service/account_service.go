@@ -0,0 +1,139 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"store-management/internal/model"
+	"store-management/internal/repository"
+	"strconv"
+	"time"
+)
+
+const exportPageSize = 500
+
+type AccountService interface {
+	Export(ctx context.Context, user *model.User, w io.Writer) error
+	DeleteAccount(ctx context.Context, user *model.User, password string) error
+}
+
+type accountServiceImpl struct {
+	repo struct {
+		user    repository.UserRepository
+		store   repository.StoreRepository
+		product repository.ProductRepository
+	}
+}
+
+type exportedUser struct {
+	ID          int64  `json:"id"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+var exportedProductHeader = []string{"store_id", "id", "category", "name", "price", "cost", "description", "barcode", "expiry_date", "size"}
+
+// Export writes a ZIP archive of the personal data of the user: the account
+// in user.json, every store they belong to in stores.json and the products of
+// the stores they own in products.csv. Products are read page by page, so
+// large stores are streamed rather than loaded at once.
+func (s *accountServiceImpl) Export(ctx context.Context, user *model.User, w io.Writer) error {
+	stores, err := s.repo.store.FindStoresByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	if err := writeJSONFile(archive, "user.json", exportedUser{ID: user.ID, PhoneNumber: user.PhoneNumber}); err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "stores.json", stores); err != nil {
+		return err
+	}
+
+	file, err := archive.Create("products.csv")
+	if err != nil {
+		return err
+	}
+	products := csv.NewWriter(file)
+	if err := products.Write(exportedProductHeader); err != nil {
+		return err
+	}
+	for _, store := range stores {
+		if store.UserID != user.ID {
+			continue
+		}
+		if err := s.exportProducts(ctx, products, store.ID); err != nil {
+			return err
+		}
+	}
+	products.Flush()
+	if err := products.Error(); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func (s *accountServiceImpl) exportProducts(ctx context.Context, w *csv.Writer, storeId int64) error {
+	var cursor int64
+	for {
+		products, err := s.repo.product.FindProductsWithPagination(ctx, storeId, cursor, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			err := w.Write([]string{
+				strconv.FormatInt(storeId, 10),
+				strconv.FormatInt(product.ID, 10),
+				product.Category,
+				product.Name,
+				strconv.FormatFloat(product.Price, 'f', -1, 64),
+				strconv.FormatFloat(product.Cost, 'f', -1, 64),
+				product.Description,
+				product.Barcode,
+				product.ExpiryDate.Format(time.DateOnly),
+				product.Size,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(products) < exportPageSize {
+			return nil
+		}
+		cursor = products[len(products)-1].ID
+	}
+}
+
+func writeJSONFile(archive *zip.Writer, name string, v interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// DeleteAccount erases the user and the stores they own after checking their
+// password. Their tokens stop working with the account, since the user they
+// name no longer exists.
+func (s *accountServiceImpl) DeleteAccount(ctx context.Context, user *model.User, password string) error {
+	matched, _, err := argon2IDHash.Verify(user.Password, password, []byte(user.PhoneNumber))
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return ErrInvalidPassword
+	}
+	return s.repo.user.DeleteUser(ctx, user)
+}
+
+func NewAccountService(repo repository.Repository) AccountService {
+	service := &accountServiceImpl{}
+	service.repo.user = repo.UserRepository()
+	service.repo.store = repo.StoreRepository()
+	service.repo.product = repo.ProductRepository()
+	return service
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"store-management/internal/model"
+	mock2 "store-management/mock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccountServiceSuite struct {
+	suite.Suite
+	mockedUserRepository    *mock2.UserRepositoryMock
+	mockedStoreRepository   *mock2.StoreRepositoryMock
+	mockedProductRepository *mock2.ProductRepositoryMock
+	service                 AccountService
+}
+
+func (s *AccountServiceSuite) SetupTest() {
+	s.mockedUserRepository = &mock2.UserRepositoryMock{}
+	s.mockedStoreRepository = &mock2.StoreRepositoryMock{}
+	s.mockedProductRepository = &mock2.ProductRepositoryMock{}
+	mockRepo := mock2.NewMockedRepository(s.mockedUserRepository, s.mockedStoreRepository, &mock2.SessionRepositoryMock{})
+	mockRepo.On("ProductRepository").Return(s.mockedProductRepository)
+	s.service = NewAccountService(mockRepo)
+}
+
+func (s *AccountServiceSuite) readZip(data []byte) map[string]string {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	s.Require().NoError(err)
+
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		s.Require().NoError(err)
+		content, err := io.ReadAll(r)
+		s.Require().NoError(err)
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func (s *AccountServiceSuite) TestExport() {
+	user := &model.User{ID: 1, PhoneNumber: "01012345678", Password: "secret-hash"}
+	s.mockedStoreRepository.On("FindStoresByUserID", int64(1)).Return([]*model.Store{
+		{ID: 10, UserID: 1, Name: "본점", Role: model.RoleOwner},
+		{ID: 20, UserID: 2, Name: "다른 가게", Role: model.RoleStaff},
+	}, nil).Once()
+	s.mockedProductRepository.On("FindProductsWithPagination", int64(10), int64(0), int64(exportPageSize)).Return([]*model.Product{
+		{ID: 2, Name: "아메리카노", Price: 4500, Cost: 1200, ExpiryDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 1, Name: "라떼", Price: 5000, Cost: 1500, ExpiryDate: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}, nil).Once()
+
+	var buf bytes.Buffer
+	err := s.service.Export(context.Background(), user, &buf)
+	s.Require().NoError(err)
+
+	files := s.readZip(buf.Bytes())
+	s.Contains(files["user.json"], `"phone_number": "01012345678"`)
+	s.NotContains(files["user.json"], "secret-hash")
+	s.Contains(files["stores.json"], "본점")
+	s.Contains(files["stores.json"], "다른 가게")
+
+	rows, err := csv.NewReader(bytes.NewReader([]byte(files["products.csv"]))).ReadAll()
+	s.Require().NoError(err)
+	s.Len(rows, 3)
+	s.Equal([]string{"10", "2", "", "아메리카노", "4500", "1200", "", "", "2024-03-01", ""}, rows[1])
+
+	s.mockedProductRepository.AssertNotCalled(s.T(), "FindProductsWithPagination", int64(20), mock.Anything, mock.Anything)
+}
+
+func (s *AccountServiceSuite) TestExport_Pages() {
+	user := &model.User{ID: 1}
+	s.mockedStoreRepository.On("FindStoresByUserID", int64(1)).Return([]*model.Store{{ID: 10, UserID: 1}}, nil).Once()
+	page := make([]*model.Product, exportPageSize)
+	for i := range page {
+		page[i] = &model.Product{ID: int64(exportPageSize + 1 - i)}
+	}
+	s.mockedProductRepository.On("FindProductsWithPagination", int64(10), int64(0), int64(exportPageSize)).Return(page, nil).Once()
+	s.mockedProductRepository.On("FindProductsWithPagination", int64(10), int64(2), int64(exportPageSize)).Return([]*model.Product{{ID: 1}}, nil).Once()
+
+	var buf bytes.Buffer
+	s.Require().NoError(s.service.Export(context.Background(), user, &buf))
+
+	rows, err := csv.NewReader(bytes.NewReader([]byte(s.readZip(buf.Bytes())["products.csv"]))).ReadAll()
+	s.Require().NoError(err)
+	s.Len(rows, exportPageSize+2)
+
+	s.mockedProductRepository.AssertExpectations(s.T())
+}
+
+func (s *AccountServiceSuite) TestDeleteAccount() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+	user := &model.User{ID: 1, PhoneNumber: "01012345678", Password: encryptedPassword}
+	s.mockedUserRepository.On("DeleteUser", user).Return(nil).Once()
+
+	err = s.service.DeleteAccount(context.Background(), user, "password")
+	s.NoError(err)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *AccountServiceSuite) TestDeleteAccount_WrongPassword_Error() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+	user := &model.User{ID: 1, PhoneNumber: "01012345678", Password: encryptedPassword}
+
+	err = s.service.DeleteAccount(context.Background(), user, "wrong")
+	s.ErrorIs(err, ErrInvalidPassword)
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "DeleteUser", mock.Anything)
+}
+
+func TestAccountServiceSuite(t *testing.T) {
+	suite.Run(t, new(AccountServiceSuite))
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"store-management/internal/apperror"
+	"store-management/internal/datasource"
+	"store-management/internal/logging"
+	"store-management/internal/model"
+	"store-management/internal/repository"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound = apperror.New(apperror.KindNotFound, "api key not found")
+	ErrInvalidAPIKey  = apperror.New(apperror.KindUnauthorized, "invalid api key")
+	ErrInvalidScope   = apperror.New(apperror.KindValidation, "invalid scope")
+)
+
+const (
+	apiKeyPrefix     = "sm_"
+	apiKeyPrefixLen  = 8
+	apiKeyTouchDelay = time.Minute
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, storeId int64, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error)
+	GetAPIKey(ctx context.Context, storeId, keyId int64) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context, storeId int64) ([]*model.APIKey, error)
+	UpdateAPIKey(ctx context.Context, key *model.APIKey) error
+	DeleteAPIKey(ctx context.Context, storeId, keyId int64) error
+	Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error)
+}
+
+type apiKeyServiceImpl struct {
+	repo struct {
+		apiKey repository.APIKeyRepository
+	}
+}
+
+func hashAPIKey(rawKey string) string {
+	hash := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !model.IsValidScope(scope) {
+			return ErrInvalidScope
+		}
+	}
+	return nil
+}
+
+// CreateAPIKey generates a new key for the store. The plain key is returned
+// only here; afterwards only its hash and display prefix are kept.
+func (s *apiKeyServiceImpl) CreateAPIKey(ctx context.Context, storeId int64, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	rawKey := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	key := &model.APIKey{
+		StoreID:   storeId,
+		Name:      name,
+		Prefix:    rawKey[:len(apiKeyPrefix)+apiKeyPrefixLen],
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	id, err := s.repo.apiKey.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	key.ID = id
+	return key, rawKey, nil
+}
+
+func (s *apiKeyServiceImpl) GetAPIKey(ctx context.Context, storeId, keyId int64) (*model.APIKey, error) {
+	key, err := s.repo.apiKey.FindAPIKey(ctx, storeId, keyId)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *apiKeyServiceImpl) ListAPIKeys(ctx context.Context, storeId int64) ([]*model.APIKey, error) {
+	return s.repo.apiKey.FindAPIKeysByStoreID(ctx, storeId)
+}
+
+func (s *apiKeyServiceImpl) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	if err := validateScopes(key.Scopes); err != nil {
+		return err
+	}
+	if err := s.repo.apiKey.UpdateAPIKey(ctx, key); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *apiKeyServiceImpl) DeleteAPIKey(ctx context.Context, storeId, keyId int64) error {
+	if err := s.repo.apiKey.DeleteAPIKey(ctx, storeId, keyId); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Authenticate resolves a presented key. last_used_at is only written when it
+// is older than apiKeyTouchDelay so that busy integrations don't turn every
+// request into a write.
+func (s *apiKeyServiceImpl) Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.apiKey.FindAPIKeyByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.Expired(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchDelay {
+		if err := s.repo.apiKey.TouchAPIKey(ctx, key.ID, now); err != nil {
+			slog.WarnContext(ctx, "failed to update last used time of api key", "api_key_id", key.ID, logging.Error(err))
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+func NewAPIKeyService(repo repository.Repository) APIKeyService {
+	service := &apiKeyServiceImpl{}
+	service.repo.apiKey = repo.APIKeyRepository()
+	return service
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	mock2 "store-management/mock"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeyServiceSuite struct {
+	suite.Suite
+	mockedAPIKeyRepository *mock2.APIKeyRepositoryMock
+	service                APIKeyService
+}
+
+func (s *APIKeyServiceSuite) SetupTest() {
+	s.mockedAPIKeyRepository = &mock2.APIKeyRepositoryMock{}
+	mockRepo := &mock2.MockedRepository{}
+	mockRepo.On("APIKeyRepository").Return(s.mockedAPIKeyRepository)
+	s.service = NewAPIKeyService(mockRepo)
+}
+
+func (s *APIKeyServiceSuite) TestCreateAPIKey_StoresOnlyHash() {
+	var stored *model.APIKey
+	s.mockedAPIKeyRepository.On("CreateAPIKey", mock.AnythingOfType("*model.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*model.APIKey)
+	}).Return(int64(1), nil).Once()
+
+	key, rawKey, err := s.service.CreateAPIKey(context.Background(), 1, "scanner", []string{model.ScopeProductsRead}, nil)
+	s.NoError(err)
+	s.True(strings.HasPrefix(rawKey, apiKeyPrefix))
+	s.Equal(int64(1), key.ID)
+	s.Equal(hashAPIKey(rawKey), stored.KeyHash)
+	s.True(strings.HasPrefix(rawKey, stored.Prefix))
+	s.NotContains(stored.KeyHash, rawKey)
+
+	s.mockedAPIKeyRepository.AssertExpectations(s.T())
+}
+
+func (s *APIKeyServiceSuite) TestCreateAPIKey_InvalidScope_Error() {
+	_, _, err := s.service.CreateAPIKey(context.Background(), 1, "scanner", []string{"products:admin"}, nil)
+	s.ErrorIs(err, ErrInvalidScope)
+
+	s.mockedAPIKeyRepository.AssertNotCalled(s.T(), "CreateAPIKey", mock.Anything)
+}
+
+func (s *APIKeyServiceSuite) TestAuthenticate_TouchesLastUsed() {
+	rawKey := apiKeyPrefix + "secret"
+	key := &model.APIKey{ID: 1, StoreID: 1, Scopes: model.Scopes{model.ScopeProductsRead}}
+
+	s.mockedAPIKeyRepository.On("FindAPIKeyByHash", hashAPIKey(rawKey)).Return(key, nil).Once()
+	s.mockedAPIKeyRepository.On("TouchAPIKey", int64(1), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	authenticated, err := s.service.Authenticate(context.Background(), rawKey)
+	s.NoError(err)
+	s.NotNil(authenticated.LastUsedAt)
+
+	s.mockedAPIKeyRepository.AssertExpectations(s.T())
+}
+
+func (s *APIKeyServiceSuite) TestAuthenticate_RecentlyUsed_SkipsTouch() {
+	rawKey := apiKeyPrefix + "secret"
+	lastUsedAt := time.Now()
+	key := &model.APIKey{ID: 1, StoreID: 1, LastUsedAt: &lastUsedAt}
+
+	s.mockedAPIKeyRepository.On("FindAPIKeyByHash", hashAPIKey(rawKey)).Return(key, nil).Once()
+
+	_, err := s.service.Authenticate(context.Background(), rawKey)
+	s.NoError(err)
+
+	s.mockedAPIKeyRepository.AssertNotCalled(s.T(), "TouchAPIKey", mock.Anything, mock.Anything)
+}
+
+func (s *APIKeyServiceSuite) TestAuthenticate_Expired_Error() {
+	rawKey := apiKeyPrefix + "secret"
+	expiresAt := time.Now().Add(-time.Minute)
+
+	s.mockedAPIKeyRepository.On("FindAPIKeyByHash", hashAPIKey(rawKey)).Return(&model.APIKey{ID: 1, ExpiresAt: &expiresAt}, nil).Once()
+
+	_, err := s.service.Authenticate(context.Background(), rawKey)
+	s.ErrorIs(err, ErrInvalidAPIKey)
+}
+
+func (s *APIKeyServiceSuite) TestAuthenticate_Unknown_Error() {
+	rawKey := apiKeyPrefix + "secret"
+
+	s.mockedAPIKeyRepository.On("FindAPIKeyByHash", hashAPIKey(rawKey)).Return(nil, datasource.ErrNoRows).Once()
+
+	_, err := s.service.Authenticate(context.Background(), rawKey)
+	s.ErrorIs(err, ErrInvalidAPIKey)
+}
+
+func TestAPIKeyServiceSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyServiceSuite))
+}
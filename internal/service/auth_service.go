@@ -1,125 +1,415 @@
 package service
 
 import (
-	"bytes"
-	"database/sql"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"log/slog"
+	"store-management/internal/apperror"
 	"store-management/internal/datasource"
+	"store-management/internal/logging"
 	"store-management/internal/model"
+	"store-management/internal/phone"
 	"store-management/internal/repository"
-
-	"golang.org/x/crypto/argon2"
+	"store-management/internal/token"
+	"time"
 )
 
 var (
-	ErrDuplicateUser = errors.New("duplicate user")
-	ErrUserNotFound  = errors.New("user not found")
+	ErrDuplicateUser       = apperror.New(apperror.KindConflict, "duplicate user")
+	ErrUserNotFound        = apperror.New(apperror.KindNotFound, "user not found")
+	ErrInvalidRefreshToken = apperror.New(apperror.KindUnauthorized, "invalid refresh token")
+	ErrRefreshTokenReused  = apperror.New(apperror.KindUnauthorized, "refresh token reused")
+	ErrInvalidPassword     = apperror.New(apperror.KindForbidden, "invalid password")
 )
 
 type AuthService interface {
-	Register(phoneNumber string, password string) error
-	Login(phoneNumber string, password string) (*model.User, error)
-	Logout(authToken string) error
+	Register(ctx context.Context, phoneNumber string, password string, verificationToken string) error
+	Login(ctx context.Context, phoneNumber string, password string, ip string) (*model.User, error)
+	CompleteLogin(ctx context.Context, userId int64) error
+	Logout(ctx context.Context, claims *token.Claims) error
+	CreateSession(ctx context.Context, userId int64, client *model.SessionClient) (*AuthTokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*AuthTokens, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	ChangePassword(ctx context.Context, user *model.User, currentPassword string, newPassword string) error
+	SendPasswordResetCode(ctx context.Context, phoneNumber string) error
+	ResetPassword(ctx context.Context, phoneNumber string, verificationToken string, newPassword string) error
+}
+
+type AuthTokens struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
 }
 
 type authServiceImpl struct {
-	repo struct {
-		user  repository.UserRepository
-		store repository.StoreRepository
+	verificationService VerificationService
+	throttle            loginThrottle
+	repo                struct {
+		user      repository.UserRepository
+		store     repository.StoreRepository
+		session   repository.SessionRepository
+		authEvent repository.AuthEventRepository
 	}
+	transaction datasource.Transaction
 }
 
-type Argon2idHash struct {
-	time    uint32
-	memory  uint32
-	threads uint8
-	keyLen  uint32
-	saltLen uint32
+// Register creates an account for a phone number that was verified with
+// VerificationService; verificationToken is the token returned by VerifyCode.
+func (s authServiceImpl) Register(ctx context.Context, phoneNumber string, password string, verificationToken string) error {
+	phoneNumber, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repo.user.FindUser(ctx, phoneNumber)
+	if err != nil && !errors.Is(err, datasource.ErrNoRows) {
+		return err
+	}
+	if user != nil {
+		return ErrDuplicateUser
+	}
+
+	err = s.verificationService.ConsumeVerificationToken(ctx, model.VerificationPurposeRegister, phoneNumber, verificationToken)
+	if err != nil {
+		return err
+	}
+
+	encryptedPassword, err := argon2IDHash.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	// The account and its first store are created together, so that a
+	// failure cannot leave an account without a store behind.
+	return s.transaction.WithinTx(ctx, func(ctx context.Context, _ datasource.TxExecer) error {
+		if err := s.repo.user.CreateUser(ctx, phoneNumber, encryptedPassword); err != nil {
+			if errors.Is(err, datasource.ErrDuplicateEntry) {
+				return ErrDuplicateUser
+			}
+			return err
+		}
+
+		user, err := s.repo.user.FindUser(ctx, phoneNumber)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.repo.store.CreateStore(ctx, &model.Store{
+			UserID:   user.ID,
+			Timezone: model.DefaultTimezone,
+		})
+		return err
+	})
 }
 
-var argon2IDHash = &Argon2idHash{
-	time:    1,
-	saltLen: 32,
-	memory:  64 * 1024,
-	threads: 32,
-	keyLen:  256,
+// Login checks the password of the account of phoneNumber. Failed attempts
+// are throttled per phone number and per client ip; while throttled it
+// returns a RateLimitError wrapping ErrTooManyLoginAttempts without checking
+// the password at all. The failures of the phone number are only forgotten
+// by CompleteLogin.
+func (s authServiceImpl) Login(ctx context.Context, phoneNumber string, password string, ip string) (*model.User, error) {
+	throttleKeys := loginThrottleKeys(normalizePhoneNumberForLookup(phoneNumber), ip)
+	lockedOut, err := s.throttle.attempt(ctx, throttleKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUserByPhoneNumber(ctx, phoneNumber)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, s.failLogin(ctx, lockedOut, nil, phoneNumber, ip)
+		}
+		s.throttle.refund(ctx, throttleKeys)
+		return nil, err
+	}
+
+	matched, needsRehash, err := argon2IDHash.Verify(user.Password, password, []byte(user.PhoneNumber))
+	if err != nil {
+		s.throttle.refund(ctx, throttleKeys)
+		return nil, err
+	}
+	if !matched {
+		return nil, s.failLogin(ctx, lockedOut, &user.ID, phoneNumber, ip)
+	}
+
+	s.throttle.refund(ctx, throttleKeys)
+	s.recordAuthEvent(ctx, &model.AuthEvent{UserID: &user.ID, PhoneNumber: user.PhoneNumber, Type: model.AuthEventLoginSucceeded, IP: ip})
+
+	if needsRehash {
+		if encryptedPassword, err := argon2IDHash.Hash(password); err == nil {
+			if err := s.repo.user.UpdatePassword(ctx, user.ID, encryptedPassword); err != nil {
+				slog.WarnContext(ctx, "failed to rehash password", "user_id", user.ID, logging.Error(err))
+			} else {
+				user.Password = encryptedPassword
+			}
+		}
+	}
+	return user, nil
+}
+
+// failLogin records a failed login, already counted by the throttle, and
+// returns the error to report for it.
+func (s authServiceImpl) failLogin(ctx context.Context, lockedOut bool, userId *int64, phoneNumber string, ip string) error {
+	if lockedOut {
+		s.recordAuthEvent(ctx, &model.AuthEvent{UserID: userId, PhoneNumber: normalizePhoneNumberForLookup(phoneNumber), Type: model.AuthEventLockedOut, IP: ip})
+	}
+	return ErrUserNotFound
 }
 
-func (a *Argon2idHash) GenerateHash(password, salt []byte) []byte {
-	if len(salt) == 0 {
-		panic("salt cannot be empty")
+// CompleteLogin forgets the failed logins of the phone number of the user
+// once every factor of a login has been checked. Login does not, so that a
+// guessed password cannot reset the count while the second factor is
+// pending.
+func (s authServiceImpl) CompleteLogin(ctx context.Context, userId int64) error {
+	user, err := s.repo.user.FindUserByID(ctx, userId)
+	if err != nil {
+		return err
 	}
-	hash := argon2.IDKey(password, salt, a.time, a.memory, a.threads, a.keyLen)
-	return hash
+	s.throttle.succeed(ctx, loginThrottleKeys(normalizePhoneNumberForLookup(user.PhoneNumber), ""))
+	return nil
 }
 
-func (a *Argon2idHash) Compare(hash, salt, password []byte) bool {
-	generatedHash := a.GenerateHash(password, salt)
-	return bytes.Equal(hash, generatedHash)
+// recordAuthEvent stores an auth event. Failing to do so must not fail the
+// request, so errors are only logged.
+func (s authServiceImpl) recordAuthEvent(ctx context.Context, event *model.AuthEvent) {
+	if err := s.repo.authEvent.CreateAuthEvent(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "failed to record auth event", "event_type", event.Type, logging.Error(err))
+	}
 }
 
-func (s authServiceImpl) Register(phoneNumber string, password string) error {
-	user, err := s.repo.user.FindUser(phoneNumber)
-	if err != nil && !errors.Is(err, datasource.ErrNoRows) {
-		panic(err)
+// normalizePhoneNumberForLookup returns the normalized phone number, or the
+// input as is when it cannot be normalized.
+func normalizePhoneNumberForLookup(phoneNumber string) string {
+	if normalized, err := phone.Normalize(phoneNumber); err == nil {
+		return normalized
 	}
-	if user != nil {
-		return ErrDuplicateUser
+	return phoneNumber
+}
+
+// findUserByPhoneNumber looks up the normalized number first and falls back
+// to the raw input for accounts registered before numbers were normalized.
+func (s authServiceImpl) findUserByPhoneNumber(ctx context.Context, phoneNumber string) (*model.User, error) {
+	normalized, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return s.repo.user.FindUser(ctx, phoneNumber)
+	}
+	user, err := s.repo.user.FindUser(ctx, normalized)
+	if errors.Is(err, datasource.ErrNoRows) && normalized != phoneNumber {
+		return s.repo.user.FindUser(ctx, phoneNumber)
+	}
+	return user, err
+}
+
+func (s authServiceImpl) Logout(ctx context.Context, claims *token.Claims) error {
+	if err := s.repo.user.BlockAuthToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
 	}
+	if claims.SessionID != "" {
+		return s.repo.session.RevokeSessionFamily(ctx, claims.SessionID)
+	}
+	return nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	hash := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(hash[:])
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	hash := argon2IDHash.GenerateHash([]byte(password), []byte(phoneNumber))
-	encryptedPassword := hex.EncodeToString(hash)
+func (s authServiceImpl) issueTokens(session *model.Session, refreshToken string) (*AuthTokens, error) {
+	accessToken, claims, err := token.Issue(session.UserID, session.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthTokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  claims.ExpiresAt.Time,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
 
-	err = s.repo.user.CreateUser(phoneNumber, encryptedPassword)
+// CreateSession starts a new refresh token family for the user on the given
+// client and returns the first access/refresh token pair of that family.
+func (s authServiceImpl) CreateSession(ctx context.Context, userId int64, client *model.SessionClient) (*AuthTokens, error) {
+	familyId, err := token.NewID()
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrDuplicateUser
+		return nil, err
+	}
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.Session{
+		FamilyID:  familyId,
+		UserID:    userId,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(token.RefreshTokenTTL),
+	}
+	device := &model.DeviceSession{
+		DeviceLabel: truncate(client.DeviceLabel, 64),
+		UserAgent:   truncate(client.UserAgent, 512),
+		IP:          client.IP,
+	}
+	if err := s.repo.session.CreateSession(ctx, session, device); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(session, refreshToken)
+}
+
+// truncate shortens s to at most n characters to fit a column.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// Refresh exchanges a refresh token for a new token pair. Each refresh token
+// can be used exactly once; presenting one that was already rotated means it
+// has leaked, so the whole family is revoked.
+func (s authServiceImpl) Refresh(ctx context.Context, refreshToken string) (*AuthTokens, error) {
+	session, err := s.repo.session.FindSessionByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
 		}
-		panic(err)
+		return nil, err
+	}
+	if session.RevokedAt != nil || !session.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.RotatedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, session.FamilyID)
 	}
 
-	user, err = s.repo.user.FindUser(phoneNumber)
+	nextRefreshToken, err := newRefreshToken()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	next := &model.Session{
+		FamilyID:  session.FamilyID,
+		UserID:    session.UserID,
+		TokenHash: hashRefreshToken(nextRefreshToken),
+		ExpiresAt: time.Now().Add(token.RefreshTokenTTL),
+	}
+	if err := s.repo.session.RotateSession(ctx, session.ID, next); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, s.revokeReusedFamily(ctx, session.FamilyID)
+		}
+		return nil, err
+	}
+	return s.issueTokens(next, nextRefreshToken)
+}
 
-	err = s.repo.store.CreateStore(user.ID)
+func (s authServiceImpl) revokeReusedFamily(ctx context.Context, familyId string) error {
+	if err := s.repo.session.RevokeSessionFamily(ctx, familyId); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s authServiceImpl) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	session, err := s.repo.session.FindSessionByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	return s.repo.session.RevokeSessionFamily(ctx, session.FamilyID)
+}
 
-	return err
+// ChangePassword sets a new password after checking the current one. Every
+// session and access token of the user stops working, including the one used
+// for this request.
+func (s authServiceImpl) ChangePassword(ctx context.Context, user *model.User, currentPassword string, newPassword string) error {
+	matched, _, err := argon2IDHash.Verify(user.Password, currentPassword, []byte(user.PhoneNumber))
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return ErrInvalidPassword
+	}
+	return s.setPassword(ctx, user.ID, newPassword)
 }
 
-func (s authServiceImpl) Login(phoneNumber string, password string) (*model.User, error) {
-	user, err := s.repo.user.FindUser(phoneNumber)
+// SendPasswordResetCode sends a password reset code to phoneNumber. Nothing is
+// sent when no account uses the number, but no error is returned either so
+// the response does not reveal which numbers are registered.
+func (s authServiceImpl) SendPasswordResetCode(ctx context.Context, phoneNumber string) error {
+	normalized, err := phone.Normalize(phoneNumber)
 	if err != nil {
+		return err
+	}
+	if _, err := s.findUserByPhoneNumber(ctx, phoneNumber); err != nil {
 		if errors.Is(err, datasource.ErrNoRows) {
-			return nil, ErrUserNotFound
+			return nil
 		}
-		panic(err)
+		return err
 	}
+	return s.verificationService.SendCode(ctx, model.VerificationPurposePasswordReset, normalized)
+}
 
-	storedPassword, err := hex.DecodeString(user.Password)
+// ResetPassword sets a new password for the account of phoneNumber, whose
+// ownership was proven with VerificationService; verificationToken is the
+// token returned by VerifyCode for the password reset purpose.
+func (s authServiceImpl) ResetPassword(ctx context.Context, phoneNumber string, verificationToken string, newPassword string) error {
+	err := s.verificationService.ConsumeVerificationToken(ctx, model.VerificationPurposePasswordReset, phoneNumber, verificationToken)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	salt := []byte(user.PhoneNumber)
-	bytedPassword := []byte(password)
 
-	if passwordMatched := argon2IDHash.Compare(storedPassword, salt, bytedPassword); passwordMatched {
-		return user, nil
-	} else {
-		return nil, ErrUserNotFound
+	user, err := s.findUserByPhoneNumber(ctx, phoneNumber)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
 	}
+	return s.setPassword(ctx, user.ID, newPassword)
 }
 
-func (s authServiceImpl) Logout(authToken string) error {
-	_ = s.repo.user.BlockAuthToken(authToken)
-	return nil
+// setPassword replaces the password and invalidates everything issued with the
+// old one: refresh token families and access tokens alike. It is one unit of
+// work, so a failure never leaves the old sessions valid with a new password.
+func (s authServiceImpl) setPassword(ctx context.Context, userId int64, password string) error {
+	encryptedPassword, err := argon2IDHash.Hash(password)
+	if err != nil {
+		return err
+	}
+	return s.transaction.WithinTx(ctx, func(ctx context.Context, _ datasource.TxExecer) error {
+		if err := s.repo.user.UpdatePassword(ctx, userId, encryptedPassword); err != nil {
+			return err
+		}
+		if err := s.repo.user.InvalidateAuthTokens(ctx, userId, time.Now()); err != nil {
+			return err
+		}
+		return s.repo.session.RevokeUserSessions(ctx, userId)
+	})
 }
 
-func NewAuthService(repo repository.Repository) AuthService {
-	service := authServiceImpl{}
+func NewAuthService(repo repository.Repository, verificationService VerificationService) AuthService {
+	service := authServiceImpl{verificationService: verificationService}
 	service.repo.user = repo.UserRepository()
 	service.repo.store = repo.StoreRepository()
+	service.repo.session = repo.SessionRepository()
+	service.repo.authEvent = repo.AuthEventRepository()
+	service.transaction = repo.Transaction()
+	service.throttle = loginThrottle{repo: repo.LoginAttemptRepository()}
 	return service
 }
@@ -1,53 +1,139 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"store-management/internal/datasource"
 	"store-management/internal/model"
+	"store-management/internal/repository"
+	"store-management/internal/sms"
+	"store-management/internal/token"
 	mock2 "store-management/mock"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type AuthServiceSuite struct {
 	suite.Suite
-	mockedUserRepository  *mock2.UserRepositoryMock
-	mockedStoreRepository *mock2.StoreRepositoryMock
-	service               AuthService
+	mockedUserRepository      *mock2.UserRepositoryMock
+	mockedStoreRepository     *mock2.StoreRepositoryMock
+	mockedSessionRepository   *mock2.SessionRepositoryMock
+	mockedAuthEventRepository *mock2.AuthEventRepositoryMock
+	verificationRepository    repository.VerificationRepository
+	loginAttemptRepository    repository.LoginAttemptRepository
+	transaction               *mock2.FakeTransaction
+	service                   AuthService
 }
 
 func (s *AuthServiceSuite) SetupTest() {
+	keys, err := token.NewHMACKeySet([]byte("test-secret-of-at-least-32-bytes"))
+	s.Require().NoError(err)
+	token.SetKeySet(keys)
 	s.mockedUserRepository = &mock2.UserRepositoryMock{}
 	s.mockedStoreRepository = &mock2.StoreRepositoryMock{}
-	mockRepo := mock2.NewMockedRepository(s.mockedUserRepository, s.mockedStoreRepository)
-	s.service = NewAuthService(mockRepo)
+	s.mockedSessionRepository = &mock2.SessionRepositoryMock{}
+	s.mockedAuthEventRepository = &mock2.AuthEventRepositoryMock{}
+	s.mockedAuthEventRepository.On("CreateAuthEvent", mock.Anything).Return(nil).Maybe()
+	s.verificationRepository = repository.NewVerificationRepository(datasource.NewInMemoryCache())
+	s.loginAttemptRepository = repository.NewLoginAttemptRepository(datasource.NewInMemoryCache())
+	mockRepo := mock2.NewMockedRepository(s.mockedUserRepository, s.mockedStoreRepository, s.mockedSessionRepository)
+	mockRepo.On("VerificationRepository").Return(s.verificationRepository)
+	mockRepo.On("AuthEventRepository").Return(s.mockedAuthEventRepository)
+	mockRepo.On("LoginAttemptRepository").Return(s.loginAttemptRepository)
+	s.transaction = &mock2.FakeTransaction{}
+	mockRepo.On("Transaction").Return(s.transaction)
+	s.service = NewAuthService(mockRepo, NewVerificationService(mockRepo, sms.NewWriterSender(&bytes.Buffer{})))
+}
+
+func (s *AuthServiceSuite) verify(phoneNumber string) string {
+	verificationToken := "verification-token"
+	err := s.verificationRepository.SaveVerifiedToken(context.Background(), model.VerificationPurposeRegister, phoneNumber, hashVerificationSecret(model.VerificationPurposeRegister, phoneNumber, verificationToken), time.Minute)
+	s.Require().NoError(err)
+	return verificationToken
 }
 
 func (s *AuthServiceSuite) TestRegister_DuplicateUser_Error() {
-	phoneNumber := "1234567890"
+	phoneNumber := "+821012345678"
 	password := "password"
 
 	s.mockedUserRepository.On("FindUser", phoneNumber).Return(&model.User{}, nil).Once()
 
-	err := s.service.Register(phoneNumber, password)
+	err := s.service.Register(context.Background(), phoneNumber, password, s.verify(phoneNumber))
 	s.EqualError(err, ErrDuplicateUser.Error())
 
 	s.mockedUserRepository.AssertExpectations(s.T())
 }
 
 func (s *AuthServiceSuite) TestRegister_Success() {
-	phoneNumber := "1234567890"
+	phoneNumber := "+821012345678"
 	password := "password"
+	verificationToken := s.verify(phoneNumber)
 
 	s.mockedUserRepository.On("FindUser", phoneNumber).Return(nil, datasource.ErrNoRows).Once()
 	s.mockedUserRepository.On("FindUser", phoneNumber).Return(&model.User{}, nil).Once()
 	s.mockedUserRepository.On("CreateUser", phoneNumber, mock.Anything).Return(nil).Once()
-	s.mockedStoreRepository.On("CreateStore", mock.Anything).Return(nil).Once()
+	s.mockedStoreRepository.On("CreateStore", mock.AnythingOfType("*model.Store")).Return(int64(1), nil).Once()
+
+	err := s.service.Register(context.Background(), "010-1234-5678", password, verificationToken)
+	s.NoError(err)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+	s.Equal(1, s.transaction.Commits())
+}
+
+func (s *AuthServiceSuite) TestRegister_StoreCreationFails_RollsBackUser() {
+	phoneNumber := "+821012345678"
+	verificationToken := s.verify(phoneNumber)
+
+	s.mockedUserRepository.On("FindUser", phoneNumber).Return(nil, datasource.ErrNoRows).Once()
+	s.mockedUserRepository.On("CreateUser", phoneNumber, mock.Anything).Return(nil).Once()
+	s.mockedUserRepository.On("FindUser", phoneNumber).Return(&model.User{ID: 1}, nil).Once()
+	s.mockedStoreRepository.On("CreateStore", mock.AnythingOfType("*model.Store")).Return(int64(0), errors.New("connection refused")).Once()
+
+	err := s.service.Register(context.Background(), phoneNumber, "password", verificationToken)
+	s.EqualError(err, "connection refused")
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+	s.Equal(0, s.transaction.Commits())
+	s.Equal(1, s.transaction.Rollbacks())
+}
+
+func (s *AuthServiceSuite) TestRegister_InvalidVerificationToken_Error() {
+	phoneNumber := "+821012345678"
+	s.verify(phoneNumber)
+
+	s.mockedUserRepository.On("FindUser", phoneNumber).Return(nil, datasource.ErrNoRows).Once()
+
+	err := s.service.Register(context.Background(), phoneNumber, "password", "wrong-token")
+	s.ErrorIs(err, ErrInvalidVerificationToken)
 
-	err := s.service.Register(phoneNumber, password)
+	s.mockedUserRepository.AssertNotCalled(s.T(), "CreateUser", mock.Anything, mock.Anything)
+}
+
+func (s *AuthServiceSuite) TestRegister_InvalidPhoneNumber_Error() {
+	err := s.service.Register(context.Background(), "not-a-number", "password", "token")
+	s.Error(err)
+	s.mockedUserRepository.AssertNotCalled(s.T(), "FindUser", mock.Anything)
+}
+
+func (s *AuthServiceSuite) TestLogin_LegacyUnnormalizedPhoneNumber() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(nil, datasource.ErrNoRows).Once()
+	s.mockedUserRepository.On("FindUser", "010-1234-5678").Return(&model.User{PhoneNumber: "010-1234-5678", Password: encryptedPassword}, nil).Once()
+
+	user, err := s.service.Login(context.Background(), "010-1234-5678", "password", "127.0.0.1")
 	s.NoError(err)
+	s.Equal("010-1234-5678", user.PhoneNumber)
 
 	s.mockedUserRepository.AssertExpectations(s.T())
 }
@@ -58,7 +144,7 @@ func (s *AuthServiceSuite) TestLogin_UserNotFound_Error() {
 
 	s.mockedUserRepository.On("FindUser", phoneNumber).Return(nil, datasource.ErrNoRows).Once()
 
-	user, err := s.service.Login(phoneNumber, password)
+	user, err := s.service.Login(context.Background(), phoneNumber, password, "127.0.0.1")
 	s.Nil(user)
 	s.EqualError(err, ErrUserNotFound.Error())
 
@@ -68,21 +154,377 @@ func (s *AuthServiceSuite) TestLogin_UserNotFound_Error() {
 func (s *AuthServiceSuite) TestLogin_Success() {
 	phoneNumber := "1234567890"
 	password := "password"
-	encryptedPassword := argon2IDHash.GenerateHash([]byte(password), []byte(phoneNumber))
+	encryptedPassword, err := argon2IDHash.Hash(password)
+	s.Require().NoError(err)
 
 	user := &model.User{
 		PhoneNumber: phoneNumber,
-		Password:    hex.EncodeToString(encryptedPassword),
+		Password:    encryptedPassword,
 	}
 
 	s.mockedUserRepository.On("FindUser", phoneNumber).Return(user, nil).Once()
 
-	user, err := s.service.Login(phoneNumber, password)
+	user, err = s.service.Login(context.Background(), phoneNumber, password, "127.0.0.1")
 	s.NoError(err)
 	s.NotNil(user)
 	s.Equal(user.PhoneNumber, phoneNumber)
 
+	s.mockedUserRepository.AssertNotCalled(s.T(), "UpdatePassword", mock.Anything, mock.Anything)
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestLogin_WrongPassword_Error() {
+	phoneNumber := "1234567890"
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("FindUser", phoneNumber).Return(&model.User{PhoneNumber: phoneNumber, Password: encryptedPassword}, nil).Once()
+
+	user, err := s.service.Login(context.Background(), phoneNumber, "wrong-password", "127.0.0.1")
+	s.Nil(user)
+	s.EqualError(err, ErrUserNotFound.Error())
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestLogin_LegacyHash_Rehashes() {
+	phoneNumber := "1234567890"
+	password := "password"
+	legacyPassword := legacyArgon2IDHash.GenerateHash([]byte(password), []byte(phoneNumber))
+
+	user := &model.User{
+		ID:          1,
+		PhoneNumber: phoneNumber,
+		Password:    hex.EncodeToString(legacyPassword),
+	}
+
+	s.mockedUserRepository.On("FindUser", phoneNumber).Return(user, nil).Once()
+	s.mockedUserRepository.On("UpdatePassword", int64(1), mock.MatchedBy(func(encoded string) bool {
+		matched, needsRehash, err := argon2IDHash.Verify(encoded, password, nil)
+		return err == nil && matched && !needsRehash
+	})).Return(nil).Once()
+
+	user, err := s.service.Login(context.Background(), phoneNumber, password, "127.0.0.1")
+	s.NoError(err)
+	s.NotNil(user)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestLogin_OutdatedParams_Rehashes() {
+	phoneNumber := "1234567890"
+	password := "password"
+	outdated := &Argon2idHash{time: 1, memory: 16 * 1024, threads: 1, keyLen: 32, saltLen: 16}
+	encryptedPassword, err := outdated.Hash(password)
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("FindUser", phoneNumber).Return(&model.User{ID: 1, PhoneNumber: phoneNumber, Password: encryptedPassword}, nil).Once()
+	s.mockedUserRepository.On("UpdatePassword", int64(1), mock.AnythingOfType("string")).Return(nil).Once()
+
+	user, err := s.service.Login(context.Background(), phoneNumber, password, "127.0.0.1")
+	s.NoError(err)
+	s.NotNil(user)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestLogin_RepositoryError_ReturnsError() {
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(nil, errors.New("connection refused")).Once()
+
+	user, err := s.service.Login(context.Background(), "+821012345678", "password", "127.0.0.1")
+	s.Nil(user)
+	s.EqualError(err, "connection refused")
+}
+
+func (s *AuthServiceSuite) TestLogin_RepeatedFailures_Throttled() {
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(nil, datasource.ErrNoRows)
+
+	for i := 0; i < phoneLoginThrottlePolicy.freeFailures+1; i++ {
+		_, err := s.service.Login(context.Background(), "+821012345678", "password", "127.0.0.1")
+		s.ErrorIs(err, ErrUserNotFound)
+	}
+
+	_, err := s.service.Login(context.Background(), "+821012345678", "password", "127.0.0.2")
+	s.ErrorIs(err, ErrTooManyLoginAttempts)
+	var rateLimitErr *RateLimitError
+	s.Require().ErrorAs(err, &rateLimitErr)
+	s.Greater(rateLimitErr.RetryAfter, time.Duration(0))
+	s.LessOrEqual(rateLimitErr.RetryAfter, phoneLoginThrottlePolicy.baseDelay)
+}
+
+func (s *AuthServiceSuite) TestLogin_BlockedIP_Throttled() {
+	err := s.loginAttemptRepository.SaveLoginAttempts(context.Background(), "ip:127.0.0.1", &model.LoginAttempts{BlockedUntil: time.Now().Add(time.Minute)}, time.Hour)
+	s.Require().NoError(err)
+
+	_, err = s.service.Login(context.Background(), "+821012345678", "password", "127.0.0.1")
+	s.ErrorIs(err, ErrTooManyLoginAttempts)
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "FindUser", mock.Anything)
+}
+
+func (s *AuthServiceSuite) TestLogin_LockoutRecordsEvent() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+	err = s.loginAttemptRepository.SaveLoginAttempts(context.Background(), "phone:+821012345678", &model.LoginAttempts{Failures: phoneLoginThrottlePolicy.lockoutFailures - 1}, time.Hour)
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(&model.User{ID: 1, PhoneNumber: "+821012345678", Password: encryptedPassword}, nil).Once()
+
+	_, err = s.service.Login(context.Background(), "+821012345678", "wrong-password", "127.0.0.1")
+	s.ErrorIs(err, ErrUserNotFound)
+
+	attempts, err := s.loginAttemptRepository.FindLoginAttempts(context.Background(), "phone:+821012345678")
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(phoneLoginThrottlePolicy.lockout), attempts.BlockedUntil, time.Second)
+	s.mockedAuthEventRepository.AssertCalled(s.T(), "CreateAuthEvent", mock.MatchedBy(func(event *model.AuthEvent) bool {
+		return event.Type == model.AuthEventLockedOut && *event.UserID == 1 && event.IP == "127.0.0.1"
+	}))
+}
+
+func (s *AuthServiceSuite) TestLogin_Success_KeepsPhoneFailuresUntilCompleteLogin() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+	err = s.loginAttemptRepository.SaveLoginAttempts(context.Background(), "phone:+821012345678", &model.LoginAttempts{Failures: 2}, time.Hour)
+	s.Require().NoError(err)
+	err = s.loginAttemptRepository.SaveLoginAttempts(context.Background(), "ip:127.0.0.1", &model.LoginAttempts{Failures: 2}, time.Hour)
+	s.Require().NoError(err)
+
+	user := &model.User{ID: 1, PhoneNumber: "+821012345678", Password: encryptedPassword}
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(user, nil).Once()
+	s.mockedUserRepository.On("FindUserByID", int64(1)).Return(user, nil).Once()
+
+	_, err = s.service.Login(context.Background(), "+821012345678", "password", "127.0.0.1")
+	s.NoError(err)
+
+	attempts, err := s.loginAttemptRepository.FindLoginAttempts(context.Background(), "phone:+821012345678")
+	s.Require().NoError(err)
+	s.Equal(2, attempts.Failures)
+	s.mockedAuthEventRepository.AssertCalled(s.T(), "CreateAuthEvent", mock.MatchedBy(func(event *model.AuthEvent) bool {
+		return event.Type == model.AuthEventLoginSucceeded && *event.UserID == 1
+	}))
+
+	s.NoError(s.service.CompleteLogin(context.Background(), 1))
+
+	attempts, err = s.loginAttemptRepository.FindLoginAttempts(context.Background(), "phone:+821012345678")
+	s.Require().NoError(err)
+	s.Zero(attempts.Failures)
+	attempts, err = s.loginAttemptRepository.FindLoginAttempts(context.Background(), "ip:127.0.0.1")
+	s.Require().NoError(err)
+	s.Equal(2, attempts.Failures)
+}
+
+func (s *AuthServiceSuite) TestLogin_ConcurrentFailures_Throttled() {
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(nil, datasource.ErrNoRows)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.service.Login(context.Background(), "+821012345678", "password", "127.0.0.1")
+			if errors.Is(err, ErrUserNotFound) {
+				mu.Lock()
+				failures++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.Equal(phoneLoginThrottlePolicy.freeFailures+1, failures)
+	attempts, err := s.loginAttemptRepository.FindLoginAttempts(context.Background(), "phone:+821012345678")
+	s.Require().NoError(err)
+	s.Equal(failures, attempts.Failures)
+}
+
+func (s *AuthServiceSuite) TestLogout_BlocksTokenIDAndRevokesSession() {
+	expiresAt := time.Now().Add(time.Hour)
+	claims := &token.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: "token-id", ExpiresAt: jwt.NewNumericDate(expiresAt)},
+		SessionID:        "family-id",
+	}
+
+	s.mockedUserRepository.On("BlockAuthToken", "token-id", claims.ExpiresAt.Time).Return(nil).Once()
+	s.mockedSessionRepository.On("RevokeSessionFamily", "family-id").Return(nil).Once()
+
+	err := s.service.Logout(context.Background(), claims)
+	s.NoError(err)
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestCreateSession_Success() {
+	s.mockedSessionRepository.On("CreateSession", mock.AnythingOfType("*model.Session"), mock.MatchedBy(func(device *model.DeviceSession) bool {
+		return device.DeviceLabel == "counter tablet" && device.IP == "127.0.0.1" && len(device.UserAgent) == 512
+	})).Return(nil).Once()
+
+	tokens, err := s.service.CreateSession(context.Background(), 1, &model.SessionClient{DeviceLabel: "counter tablet", UserAgent: strings.Repeat("a", 600), IP: "127.0.0.1"})
+	s.NoError(err)
+	s.NotEmpty(tokens.AccessToken)
+	s.NotEmpty(tokens.RefreshToken)
+
+	claims, err := token.Parse(tokens.AccessToken)
+	s.NoError(err)
+	s.NotEmpty(claims.SessionID)
+
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestRefresh_RotatesToken() {
+	session := &model.Session{ID: 1, FamilyID: "family-id", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+
+	s.mockedSessionRepository.On("FindSessionByTokenHash", hashRefreshToken("refresh-token")).Return(session, nil).Once()
+	s.mockedSessionRepository.On("RotateSession", int64(1), mock.MatchedBy(func(next *model.Session) bool {
+		return next.FamilyID == "family-id" && next.TokenHash != hashRefreshToken("refresh-token")
+	})).Return(nil).Once()
+
+	tokens, err := s.service.Refresh(context.Background(), "refresh-token")
+	s.NoError(err)
+	s.NotEqual("refresh-token", tokens.RefreshToken)
+
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestRefresh_ReusedToken_RevokesFamily() {
+	rotatedAt := time.Now()
+	session := &model.Session{ID: 1, FamilyID: "family-id", UserID: 1, ExpiresAt: time.Now().Add(time.Hour), RotatedAt: &rotatedAt}
+
+	s.mockedSessionRepository.On("FindSessionByTokenHash", hashRefreshToken("refresh-token")).Return(session, nil).Once()
+	s.mockedSessionRepository.On("RevokeSessionFamily", "family-id").Return(nil).Once()
+
+	tokens, err := s.service.Refresh(context.Background(), "refresh-token")
+	s.Nil(tokens)
+	s.ErrorIs(err, ErrRefreshTokenReused)
+
+	s.mockedSessionRepository.AssertNotCalled(s.T(), "RotateSession", mock.Anything, mock.Anything)
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestRefresh_ConcurrentRotation_RevokesFamily() {
+	session := &model.Session{ID: 1, FamilyID: "family-id", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+
+	s.mockedSessionRepository.On("FindSessionByTokenHash", hashRefreshToken("refresh-token")).Return(session, nil).Once()
+	s.mockedSessionRepository.On("RotateSession", int64(1), mock.Anything).Return(datasource.ErrNoRows).Once()
+	s.mockedSessionRepository.On("RevokeSessionFamily", "family-id").Return(nil).Once()
+
+	_, err := s.service.Refresh(context.Background(), "refresh-token")
+	s.ErrorIs(err, ErrRefreshTokenReused)
+
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestRefresh_UnknownToken_Error() {
+	s.mockedSessionRepository.On("FindSessionByTokenHash", hashRefreshToken("refresh-token")).Return(nil, datasource.ErrNoRows).Once()
+
+	_, err := s.service.Refresh(context.Background(), "refresh-token")
+	s.ErrorIs(err, ErrInvalidRefreshToken)
+
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestChangePassword_WrongPassword_Error() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+	user := &model.User{ID: 1, PhoneNumber: "+821012345678", Password: encryptedPassword}
+
+	err = s.service.ChangePassword(context.Background(), user, "wrong-password", "new-password")
+	s.ErrorIs(err, ErrInvalidPassword)
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func (s *AuthServiceSuite) TestChangePassword_InvalidatesSessions() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+	user := &model.User{ID: 1, PhoneNumber: "+821012345678", Password: encryptedPassword}
+
+	s.mockedUserRepository.On("UpdatePassword", int64(1), mock.MatchedBy(func(encoded string) bool {
+		matched, _, err := argon2IDHash.Verify(encoded, "new-password", nil)
+		return err == nil && matched
+	})).Return(nil).Once()
+	s.mockedUserRepository.On("InvalidateAuthTokens", int64(1), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	s.mockedSessionRepository.On("RevokeUserSessions", int64(1)).Return(nil).Once()
+
+	err = s.service.ChangePassword(context.Background(), user, "password", "new-password")
+	s.NoError(err)
+	s.Equal(1, s.transaction.Commits())
+
+	s.mockedUserRepository.AssertExpectations(s.T())
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceSuite) TestChangePassword_RevokeFails_RollsBack() {
+	encryptedPassword, err := argon2IDHash.Hash("password")
+	s.Require().NoError(err)
+	user := &model.User{ID: 1, PhoneNumber: "+821012345678", Password: encryptedPassword}
+	revokeErr := errors.New("revoke failed")
+
+	s.mockedUserRepository.On("UpdatePassword", int64(1), mock.Anything).Return(nil).Once()
+	s.mockedUserRepository.On("InvalidateAuthTokens", int64(1), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	s.mockedSessionRepository.On("RevokeUserSessions", int64(1)).Return(revokeErr).Once()
+
+	err = s.service.ChangePassword(context.Background(), user, "password", "new-password")
+	s.ErrorIs(err, revokeErr)
+	s.Equal(0, s.transaction.Commits())
+	s.Equal(1, s.transaction.Rollbacks())
+}
+
+func (s *AuthServiceSuite) TestSendPasswordResetCode_UnknownUser_NothingSent() {
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(nil, datasource.ErrNoRows).Once()
+	s.mockedUserRepository.On("FindUser", "010-1234-5678").Return(nil, datasource.ErrNoRows).Once()
+
+	err := s.service.SendPasswordResetCode(context.Background(), "010-1234-5678")
+	s.NoError(err)
+
+	_, err = s.verificationRepository.FindCode(context.Background(), model.VerificationPurposePasswordReset, "+821012345678")
+	s.ErrorIs(err, datasource.ErrNoRows)
+}
+
+func (s *AuthServiceSuite) TestSendPasswordResetCode_Success() {
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(&model.User{ID: 1}, nil).Once()
+
+	err := s.service.SendPasswordResetCode(context.Background(), "+821012345678")
+	s.NoError(err)
+
+	_, err = s.verificationRepository.FindCode(context.Background(), model.VerificationPurposePasswordReset, "+821012345678")
+	s.NoError(err)
+}
+
+func (s *AuthServiceSuite) TestResetPassword_InvalidVerificationToken_Error() {
+	err := s.service.ResetPassword(context.Background(), "+821012345678", "wrong-token", "new-password")
+	s.ErrorIs(err, ErrInvalidVerificationToken)
+
+	s.mockedUserRepository.AssertNotCalled(s.T(), "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func (s *AuthServiceSuite) TestResetPassword_RegisterToken_Error() {
+	verificationToken := s.verify("+821012345678")
+
+	err := s.service.ResetPassword(context.Background(), "+821012345678", verificationToken, "new-password")
+	s.ErrorIs(err, ErrInvalidVerificationToken)
+}
+
+func (s *AuthServiceSuite) TestResetPassword_InvalidatesSessions() {
+	phoneNumber := "+821012345678"
+	verificationToken := "verification-token"
+	err := s.verificationRepository.SaveVerifiedToken(context.Background(), model.VerificationPurposePasswordReset, phoneNumber, hashVerificationSecret(model.VerificationPurposePasswordReset, phoneNumber, verificationToken), time.Minute)
+	s.Require().NoError(err)
+
+	s.mockedUserRepository.On("FindUser", phoneNumber).Return(&model.User{ID: 1, PhoneNumber: phoneNumber}, nil).Once()
+	s.mockedUserRepository.On("UpdatePassword", int64(1), mock.Anything).Return(nil).Once()
+	s.mockedUserRepository.On("InvalidateAuthTokens", int64(1), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	s.mockedSessionRepository.On("RevokeUserSessions", int64(1)).Return(nil).Once()
+
+	err = s.service.ResetPassword(context.Background(), phoneNumber, verificationToken, "new-password")
+	s.NoError(err)
+
 	s.mockedUserRepository.AssertExpectations(s.T())
+	s.mockedSessionRepository.AssertExpectations(s.T())
 }
 
 func TestAuthServiceSuite(t *testing.T) {
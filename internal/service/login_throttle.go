@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"store-management/internal/apperror"
+	"store-management/internal/logging"
+	"store-management/internal/model"
+	"store-management/internal/repository"
+	"time"
+)
+
+var ErrTooManyLoginAttempts = apperror.New(apperror.KindTooManyRequests, "too many login attempts")
+
+// loginThrottlePolicy lets freeFailures consecutive failures through, then
+// makes every further attempt wait baseDelay, doubled per failure up to
+// maxDelay, and locks the key for lockout once lockoutFailures is reached.
+// Failures are forgotten window after the last one.
+type loginThrottlePolicy struct {
+	freeFailures    int
+	lockoutFailures int
+	baseDelay       time.Duration
+	maxDelay        time.Duration
+	lockout         time.Duration
+	window          time.Duration
+}
+
+var (
+	phoneLoginThrottlePolicy = loginThrottlePolicy{
+		freeFailures:    3,
+		lockoutFailures: 10,
+		baseDelay:       time.Second,
+		maxDelay:        time.Minute,
+		lockout:         15 * time.Minute,
+		window:          time.Hour,
+	}
+	// Many users can share an IP, so it gets more room than a phone number.
+	ipLoginThrottlePolicy = loginThrottlePolicy{
+		freeFailures:    10,
+		lockoutFailures: 50,
+		baseDelay:       time.Second,
+		maxDelay:        time.Minute,
+		lockout:         15 * time.Minute,
+		window:          time.Hour,
+	}
+)
+
+// delay returns how long to block after the given number of failures and
+// whether that block is a lockout.
+func (p loginThrottlePolicy) delay(failures int) (time.Duration, bool) {
+	if failures >= p.lockoutFailures {
+		return p.lockout, true
+	}
+	if failures <= p.freeFailures {
+		return 0, false
+	}
+	delay := p.baseDelay
+	for i := p.freeFailures + 1; i < failures && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return delay, false
+}
+
+type loginThrottleKey struct {
+	key    string
+	policy loginThrottlePolicy
+	// resetOnSuccess forgets the failures after a successful login. The IP
+	// keeps its count so that logging into an account of one's own does not
+	// reset an attack on others.
+	resetOnSuccess bool
+}
+
+func loginThrottleKeys(phoneNumber, ip string) []loginThrottleKey {
+	keys := []loginThrottleKey{{key: "phone:" + phoneNumber, policy: phoneLoginThrottlePolicy, resetOnSuccess: true}}
+	if ip != "" {
+		keys = append(keys, loginThrottleKey{key: "ip:" + ip, policy: ipLoginThrottlePolicy})
+	}
+	return keys
+}
+
+// ttl is how long to remember the failures of a key that is blocked for
+// delay.
+func (p loginThrottlePolicy) ttl(delay time.Duration) time.Duration {
+	if delay > p.window {
+		return delay
+	}
+	return p.window
+}
+
+type loginThrottle struct {
+	repo repository.LoginAttemptRepository
+}
+
+// errLoginBlocked stops an update of a blocked key without saving it.
+var errLoginBlocked = errors.New("login blocked")
+
+// attempt returns a RateLimitError when any of the keys is still blocked.
+// Otherwise it counts the attempt as failed for every key, in the same atomic
+// step as the check, so that concurrent attempts cannot all pass a check made
+// before any of them failed; an attempt that turns out not to have failed is
+// given back with refund. It reports whether the failure locks any key out.
+func (t loginThrottle) attempt(ctx context.Context, keys []loginThrottleKey) (bool, error) {
+	now := time.Now()
+	var (
+		counted    []loginThrottleKey
+		retryAfter time.Duration
+		lockedOut  bool
+	)
+	for _, k := range keys {
+		k := k
+		err := t.repo.UpdateLoginAttempts(ctx, k.key, func(attempts *model.LoginAttempts) (time.Duration, error) {
+			if wait := attempts.BlockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+			if retryAfter > 0 {
+				return 0, errLoginBlocked
+			}
+			attempts.Failures++
+			delay, lockout := k.policy.delay(attempts.Failures)
+			attempts.BlockedUntil = now.Add(delay)
+			lockedOut = lockedOut || lockout
+			return k.policy.ttl(delay), nil
+		})
+		if errors.Is(err, errLoginBlocked) {
+			continue
+		}
+		if err != nil {
+			t.refund(ctx, counted)
+			return false, err
+		}
+		counted = append(counted, k)
+	}
+	if retryAfter > 0 {
+		t.refund(ctx, counted)
+		return false, &RateLimitError{Err: ErrTooManyLoginAttempts, RetryAfter: retryAfter}
+	}
+	return lockedOut, nil
+}
+
+// refund takes back the failure attempt counted for every key and lifts the
+// block it set, for an attempt that did not fail.
+func (t loginThrottle) refund(ctx context.Context, keys []loginThrottleKey) {
+	now := time.Now()
+	for _, k := range keys {
+		k := k
+		err := t.repo.UpdateLoginAttempts(ctx, k.key, func(attempts *model.LoginAttempts) (time.Duration, error) {
+			if attempts.Failures > 0 {
+				attempts.Failures--
+			}
+			if attempts.BlockedUntil.After(now) {
+				attempts.BlockedUntil = now
+			}
+			return k.policy.window, nil
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "failed to refund login attempt", "key", k.key, logging.Error(err))
+		}
+	}
+}
+
+// succeed forgets the failures of the keys reset by a successful login. It is
+// only called once every factor of the login has been checked.
+func (t loginThrottle) succeed(ctx context.Context, keys []loginThrottleKey) {
+	for _, k := range keys {
+		if k.resetOnSuccess {
+			t.repo.DeleteLoginAttempts(ctx, k.key)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottlePolicy_Delay(t *testing.T) {
+	policy := loginThrottlePolicy{
+		freeFailures:    3,
+		lockoutFailures: 10,
+		baseDelay:       time.Second,
+		maxDelay:        10 * time.Second,
+		lockout:         15 * time.Minute,
+		window:          time.Hour,
+	}
+
+	tests := []struct {
+		failures  int
+		delay     time.Duration
+		lockedOut bool
+	}{
+		{failures: 1, delay: 0},
+		{failures: 3, delay: 0},
+		{failures: 4, delay: time.Second},
+		{failures: 5, delay: 2 * time.Second},
+		{failures: 7, delay: 8 * time.Second},
+		{failures: 8, delay: 10 * time.Second},
+		{failures: 10, delay: 15 * time.Minute, lockedOut: true},
+		{failures: 12, delay: 15 * time.Minute, lockedOut: true},
+	}
+	for _, test := range tests {
+		delay, lockedOut := policy.delay(test.failures)
+		assert.Equal(t, test.delay, delay, "failures %d", test.failures)
+		assert.Equal(t, test.lockedOut, lockedOut, "failures %d", test.failures)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"store-management/internal/apperror"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"store-management/internal/phone"
+	"store-management/internal/repository"
+	"time"
+)
+
+var (
+	ErrInvalidRole        = apperror.New(apperror.KindValidation, "invalid role")
+	ErrAlreadyMember      = apperror.New(apperror.KindConflict, "already a member")
+	ErrMemberNotFound     = apperror.New(apperror.KindNotFound, "member not found")
+	ErrCannotRemoveOwner  = apperror.New(apperror.KindValidation, "cannot remove the owner")
+	ErrInvitationNotFound = apperror.New(apperror.KindNotFound, "invitation not found")
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type MemberService interface {
+	ListMembers(ctx context.Context, storeId int64) ([]*model.StoreMember, error)
+	RemoveMember(ctx context.Context, storeId, userId int64) error
+	Invite(ctx context.Context, storeId int64, invitedBy int64, phoneNumber string, role model.Role) (*model.StoreInvitation, error)
+	ListInvitations(ctx context.Context, storeId int64) ([]*model.StoreInvitation, error)
+	CancelInvitation(ctx context.Context, storeId, invitationId int64) error
+	ListMyInvitations(ctx context.Context, user *model.User) ([]*model.StoreInvitation, error)
+	AcceptInvitation(ctx context.Context, user *model.User, invitationId int64) error
+}
+
+type memberServiceImpl struct {
+	repo struct {
+		user   repository.UserRepository
+		member repository.MemberRepository
+	}
+}
+
+func (s *memberServiceImpl) ListMembers(ctx context.Context, storeId int64) ([]*model.StoreMember, error) {
+	return s.repo.member.FindMembersByStoreID(ctx, storeId)
+}
+
+func (s *memberServiceImpl) RemoveMember(ctx context.Context, storeId, userId int64) error {
+	member, err := s.repo.member.FindMember(ctx, storeId, userId)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	if member.Role == model.RoleOwner {
+		return ErrCannotRemoveOwner
+	}
+
+	if err := s.repo.member.DeleteMember(ctx, storeId, userId); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Invite creates a pending invitation for phoneNumber. Ownership cannot be
+// granted by invitation.
+func (s *memberServiceImpl) Invite(ctx context.Context, storeId int64, invitedBy int64, phoneNumber string, role model.Role) (*model.StoreInvitation, error) {
+	if role != model.RoleManager && role != model.RoleStaff {
+		return nil, ErrInvalidRole
+	}
+	phoneNumber, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.user.FindUser(ctx, phoneNumber)
+	if err != nil && !errors.Is(err, datasource.ErrNoRows) {
+		return nil, err
+	}
+	if user != nil {
+		if _, err := s.repo.member.FindMember(ctx, storeId, user.ID); err == nil {
+			return nil, ErrAlreadyMember
+		} else if !errors.Is(err, datasource.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	invitation := &model.StoreInvitation{
+		StoreID:     storeId,
+		PhoneNumber: phoneNumber,
+		Role:        role,
+		InvitedBy:   invitedBy,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+		CreatedAt:   time.Now(),
+	}
+	id, err := s.repo.member.CreateInvitation(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+	invitation.ID = id
+	return invitation, nil
+}
+
+func (s *memberServiceImpl) ListInvitations(ctx context.Context, storeId int64) ([]*model.StoreInvitation, error) {
+	return s.repo.member.FindPendingInvitationsByStoreID(ctx, storeId)
+}
+
+func (s *memberServiceImpl) CancelInvitation(ctx context.Context, storeId, invitationId int64) error {
+	if err := s.repo.member.DeleteInvitation(ctx, storeId, invitationId); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListMyInvitations returns the pending invitations to the phone number of the
+// user. Invitations store normalized numbers, so the number of an account
+// registered before numbers were normalized is normalized first.
+func (s *memberServiceImpl) ListMyInvitations(ctx context.Context, user *model.User) ([]*model.StoreInvitation, error) {
+	return s.repo.member.FindPendingInvitationsByPhoneNumber(ctx, normalizePhoneNumberForLookup(user.PhoneNumber))
+}
+
+// AcceptInvitation adds the user to the inviting store. Only the owner of the
+// invited phone number can accept, and only while the invitation is pending.
+// Like ListMyInvitations it compares the normalized number of the user.
+func (s *memberServiceImpl) AcceptInvitation(ctx context.Context, user *model.User, invitationId int64) error {
+	invitation, err := s.repo.member.FindInvitation(ctx, invitationId)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	if invitation.PhoneNumber != normalizePhoneNumberForLookup(user.PhoneNumber) || invitation.AcceptedAt != nil || !invitation.ExpiresAt.After(time.Now()) {
+		return ErrInvitationNotFound
+	}
+
+	if err := s.repo.member.AcceptInvitation(ctx, invitation, user.ID); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+		if errors.Is(err, datasource.ErrDuplicateEntry) {
+			return ErrAlreadyMember
+		}
+		return err
+	}
+	return nil
+}
+
+func NewMemberService(repo repository.Repository) MemberService {
+	service := &memberServiceImpl{}
+	service.repo.user = repo.UserRepository()
+	service.repo.member = repo.MemberRepository()
+	return service
+}
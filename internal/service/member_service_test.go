@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"store-management/internal/permission"
+	mock2 "store-management/mock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MemberServiceSuite struct {
+	suite.Suite
+	mockedUserRepository   *mock2.UserRepositoryMock
+	mockedMemberRepository *mock2.MemberRepositoryMock
+	service                MemberService
+	storeService           StoreService
+}
+
+func (s *MemberServiceSuite) SetupTest() {
+	s.mockedUserRepository = &mock2.UserRepositoryMock{}
+	s.mockedMemberRepository = &mock2.MemberRepositoryMock{}
+	mockRepo := mock2.NewMockedRepository(s.mockedUserRepository, &mock2.StoreRepositoryMock{}, &mock2.SessionRepositoryMock{})
+	mockRepo.On("MemberRepository").Return(s.mockedMemberRepository)
+	mockRepo.On("ProductRepository").Return(nil)
+	s.service = NewMemberService(mockRepo)
+	s.storeService = NewStoreService(mockRepo)
+}
+
+func (s *MemberServiceSuite) TestInvite_OwnerRole_Error() {
+	_, err := s.service.Invite(context.Background(), 1, 1, "01012345678", model.RoleOwner)
+	s.ErrorIs(err, ErrInvalidRole)
+}
+
+func (s *MemberServiceSuite) TestInvite_ExistingMember_Error() {
+	s.mockedUserRepository.On("FindUser", "+821012345678").Return(&model.User{ID: 2}, nil).Once()
+	s.mockedMemberRepository.On("FindMember", int64(1), int64(2)).Return(&model.StoreMember{}, nil).Once()
+
+	_, err := s.service.Invite(context.Background(), 1, 1, "01012345678", model.RoleStaff)
+	s.ErrorIs(err, ErrAlreadyMember)
+
+	s.mockedMemberRepository.AssertNotCalled(s.T(), "CreateInvitation", mock.Anything)
+}
+
+func (s *MemberServiceSuite) TestAcceptInvitation_OtherPhoneNumber_Error() {
+	invitation := &model.StoreInvitation{ID: 1, StoreID: 1, PhoneNumber: "+821012345678", Role: model.RoleStaff, ExpiresAt: time.Now().Add(time.Hour)}
+	s.mockedMemberRepository.On("FindInvitation", int64(1)).Return(invitation, nil).Once()
+
+	err := s.service.AcceptInvitation(context.Background(), &model.User{ID: 2, PhoneNumber: "01099999999"}, 1)
+	s.ErrorIs(err, ErrInvitationNotFound)
+
+	s.mockedMemberRepository.AssertNotCalled(s.T(), "AcceptInvitation", mock.Anything, mock.Anything)
+}
+
+func (s *MemberServiceSuite) TestAcceptInvitation_Success() {
+	invitation := &model.StoreInvitation{ID: 1, StoreID: 1, PhoneNumber: "+821012345678", Role: model.RoleStaff, ExpiresAt: time.Now().Add(time.Hour)}
+	s.mockedMemberRepository.On("FindInvitation", int64(1)).Return(invitation, nil).Once()
+	s.mockedMemberRepository.On("AcceptInvitation", invitation, int64(2)).Return(nil).Once()
+
+	err := s.service.AcceptInvitation(context.Background(), &model.User{ID: 2, PhoneNumber: "+821012345678"}, 1)
+	s.NoError(err)
+
+	s.mockedMemberRepository.AssertExpectations(s.T())
+}
+
+// Accounts registered before phone numbers were normalized keep their number
+// as typed.
+func (s *MemberServiceSuite) TestAcceptInvitation_LegacyPhoneNumber() {
+	invitation := &model.StoreInvitation{ID: 1, StoreID: 1, PhoneNumber: "+821012345678", Role: model.RoleStaff, ExpiresAt: time.Now().Add(time.Hour)}
+	s.mockedMemberRepository.On("FindInvitation", int64(1)).Return(invitation, nil).Once()
+	s.mockedMemberRepository.On("AcceptInvitation", invitation, int64(2)).Return(nil).Once()
+
+	err := s.service.AcceptInvitation(context.Background(), &model.User{ID: 2, PhoneNumber: "010-1234-5678"}, 1)
+	s.NoError(err)
+
+	s.mockedMemberRepository.AssertExpectations(s.T())
+}
+
+func (s *MemberServiceSuite) TestListMyInvitations_LegacyPhoneNumber() {
+	invitations := []*model.StoreInvitation{{ID: 1, StoreID: 1, PhoneNumber: "+821012345678", Role: model.RoleStaff}}
+	s.mockedMemberRepository.On("FindPendingInvitationsByPhoneNumber", "+821012345678").Return(invitations, nil).Once()
+
+	found, err := s.service.ListMyInvitations(context.Background(), &model.User{ID: 2, PhoneNumber: "010-1234-5678"})
+	s.NoError(err)
+	s.Equal(invitations, found)
+}
+
+func (s *MemberServiceSuite) TestRemoveMember_Owner_Error() {
+	s.mockedMemberRepository.On("FindMember", int64(1), int64(1)).Return(&model.StoreMember{Role: model.RoleOwner}, nil).Once()
+
+	err := s.service.RemoveMember(context.Background(), 1, 1)
+	s.ErrorIs(err, ErrCannotRemoveOwner)
+
+	s.mockedMemberRepository.AssertNotCalled(s.T(), "DeleteMember", mock.Anything, mock.Anything)
+}
+
+func (s *MemberServiceSuite) TestResolveAccess_StaffCannotSeeCost() {
+	s.mockedMemberRepository.On("FindMembershipsByUserID", int64(2)).Return([]*model.StoreMember{{StoreID: 1, UserID: 2, Role: model.RoleStaff}}, nil).Once()
+
+	access, err := s.storeService.ResolveAccess(context.Background(), &model.User{ID: 2}, nil, 0)
+	s.NoError(err)
+	s.Equal(int64(1), access.Store.ID)
+	s.True(access.Can(permission.ProductRead))
+	s.False(access.Can(permission.ProductViewCost))
+	s.False(access.Can(permission.ProductDelete))
+}
+
+func (s *MemberServiceSuite) TestResolveAccess_NoMembership_Error() {
+	s.mockedMemberRepository.On("FindMembershipsByUserID", int64(2)).Return([]*model.StoreMember{}, nil).Once()
+
+	_, err := s.storeService.ResolveAccess(context.Background(), &model.User{ID: 2}, nil, 0)
+	s.ErrorIs(err, ErrStoreNotFound)
+}
+
+func (s *MemberServiceSuite) TestResolveAccess_ExplicitStore_NotMember_Error() {
+	s.mockedMemberRepository.On("FindMember", int64(5), int64(2)).Return(nil, datasource.ErrNoRows).Once()
+
+	_, err := s.storeService.ResolveAccess(context.Background(), &model.User{ID: 2}, nil, 5)
+	s.ErrorIs(err, ErrStoreNotFound)
+}
+
+func (s *MemberServiceSuite) TestResolveAccess_ExplicitStore_Member() {
+	s.mockedMemberRepository.On("FindMember", int64(5), int64(2)).Return(&model.StoreMember{StoreID: 5, UserID: 2, Role: model.RoleManager}, nil).Once()
+
+	access, err := s.storeService.ResolveAccess(context.Background(), &model.User{ID: 2}, nil, 5)
+	s.NoError(err)
+	s.Equal(int64(5), access.Store.ID)
+	s.True(access.Can(permission.ProductViewCost))
+}
+
+func (s *MemberServiceSuite) TestResolveAccess_APIKeyOtherStore_Error() {
+	_, err := s.storeService.ResolveAccess(context.Background(), nil, &model.APIKey{StoreID: 1, Scopes: model.Scopes{model.ScopeProductsRead}}, 5)
+	s.ErrorIs(err, ErrStoreNotFound)
+}
+
+func (s *MemberServiceSuite) TestRemoveMember_NotFound_Error() {
+	s.mockedMemberRepository.On("FindMember", int64(1), int64(3)).Return(nil, datasource.ErrNoRows).Once()
+
+	err := s.service.RemoveMember(context.Background(), 1, 3)
+	s.ErrorIs(err, ErrMemberNotFound)
+}
+
+func TestMemberServiceSuite(t *testing.T) {
+	suite.Run(t, new(MemberServiceSuite))
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+type Argon2idHash struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	keyLen  uint32
+	saltLen uint32
+}
+
+// argon2IDHash holds the parameters used for newly stored passwords. Hashes
+// encoded with different parameters keep verifying and are upgraded on the
+// next successful login.
+var argon2IDHash = &Argon2idHash{
+	time:    3,
+	memory:  64 * 1024,
+	threads: 2,
+	keyLen:  32,
+	saltLen: 16,
+}
+
+// legacyArgon2IDHash describes the hex encoded hashes salted with the phone
+// number that were stored before the PHC format was introduced.
+var legacyArgon2IDHash = &Argon2idHash{
+	time:    1,
+	memory:  64 * 1024,
+	threads: 32,
+	keyLen:  256,
+}
+
+const argon2IDPrefix = "$argon2id$"
+
+// Limits on the parameters of a stored hash, so that a bad row cannot make
+// a login panic or allocate without bound. They leave room to raise the cost
+// of argon2IDHash several times over.
+const (
+	argon2IDMaxTime    = 16
+	argon2IDMaxMemory  = 256 * 1024
+	argon2IDMaxThreads = 64
+)
+
+func (a *Argon2idHash) GenerateHash(password, salt []byte) []byte {
+	if len(salt) == 0 {
+		panic("salt cannot be empty")
+	}
+	hash := argon2.IDKey(password, salt, a.time, a.memory, a.threads, a.keyLen)
+	return hash
+}
+
+// Hash derives a key from password with a random salt and encodes it in the
+// PHC string format: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>.
+func (a *Argon2idHash) Hash(password string) (string, error) {
+	salt := make([]byte, a.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := a.GenerateHash([]byte(password), salt)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2IDPrefix, argon2.Version, a.memory, a.time, a.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// Verify checks password against an encoded hash in constant time. The legacy
+// salt is only used for hashes stored before the PHC format. needsRehash
+// reports whether a matching hash should be replaced with one generated by a.
+func (a *Argon2idHash) Verify(encoded, password string, legacySalt []byte) (matched bool, needsRehash bool, err error) {
+	if !strings.HasPrefix(encoded, argon2IDPrefix) {
+		storedHash, err := hex.DecodeString(encoded)
+		if err != nil {
+			return false, false, ErrInvalidPasswordHash
+		}
+		hash := legacyArgon2IDHash.GenerateHash([]byte(password), legacySalt)
+		return subtle.ConstantTimeCompare(storedHash, hash) == 1, true, nil
+	}
+
+	params, salt, storedHash, err := decodeArgon2IDHash(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	hash := params.GenerateHash([]byte(password), salt)
+	if subtle.ConstantTimeCompare(storedHash, hash) != 1 {
+		return false, false, nil
+	}
+	return true, !a.sameParams(params), nil
+}
+
+func (a *Argon2idHash) sameParams(other *Argon2idHash) bool {
+	return a.time == other.time && a.memory == other.memory && a.threads == other.threads &&
+		a.keyLen == other.keyLen && a.saltLen == other.saltLen
+}
+
+func decodeArgon2IDHash(encoded string) (*Argon2idHash, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params := &Argon2idHash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	// argon2 needs at least 8 KiB of memory per thread.
+	if params.time == 0 || params.time > argon2IDMaxTime ||
+		params.threads == 0 || params.threads > argon2IDMaxThreads ||
+		params.memory < 8*uint32(params.threads) || params.memory > argon2IDMaxMemory {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	params.saltLen = uint32(len(salt))
+	params.keyLen = uint32(len(hash))
+	return params, salt, hash, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgon2idHash_HashUsesPHCFormatAndRandomSalt(t *testing.T) {
+	first, err := argon2IDHash.Hash("password")
+	assert.NoError(t, err)
+	second, err := argon2IDHash.Hash("password")
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "$argon2id$v=19$m=65536,t=3,p=2$"))
+	assert.NotEqual(t, first, second)
+}
+
+func TestArgon2idHash_Verify(t *testing.T) {
+	encoded, err := argon2IDHash.Hash("password")
+	assert.NoError(t, err)
+
+	matched, needsRehash, err := argon2IDHash.Verify(encoded, "password", nil)
+	assert.NoError(t, err)
+	assert.True(t, matched)
+	assert.False(t, needsRehash)
+
+	matched, _, err = argon2IDHash.Verify(encoded, "wrong-password", nil)
+	assert.NoError(t, err)
+	assert.False(t, matched)
+}
+
+func TestArgon2idHash_Verify_MalformedHash(t *testing.T) {
+	for _, encoded := range []string{"$argon2id$v=19$m=65536$salt$hash", "$argon2id$v=18$m=65536,t=3,p=2$c2FsdA$aGFzaA", "not-hex"} {
+		_, _, err := argon2IDHash.Verify(encoded, "password", []byte("salt"))
+		assert.ErrorIs(t, err, ErrInvalidPasswordHash, encoded)
+	}
+}
+
+func TestArgon2idHash_Verify_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+	}{
+		{"zero time", "m=65536,t=0,p=2"},
+		{"zero threads", "m=65536,t=3,p=0"},
+		{"too little memory per thread", "m=15,t=3,p=2"},
+		{"too much time", "m=65536,t=17,p=2"},
+		{"too many threads", "m=65536,t=3,p=65"},
+		{"too much memory", "m=262145,t=3,p=2"},
+		{"threads overflow", "m=65536,t=3,p=256"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := "$argon2id$v=19$" + tt.params + "$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaA"
+			assert.NotPanics(t, func() {
+				_, _, err := argon2IDHash.Verify(encoded, "password", nil)
+				assert.ErrorIs(t, err, ErrInvalidPasswordHash)
+			})
+		})
+	}
+}
@@ -1,22 +1,38 @@
 package service
 
-import "store-management/internal/repository"
+import (
+	"store-management/internal/repository"
+	"store-management/internal/sms"
+)
 
 var service *Service
 
 type Service struct {
-	AuthService  AuthService
-	StoreService StoreService
+	AuthService         AuthService
+	StoreService        StoreService
+	APIKeyService       APIKeyService
+	MemberService       MemberService
+	VerificationService VerificationService
+	SessionService      SessionService
+	TwoFactorService    TwoFactorService
+	AccountService      AccountService
 }
 
-func Init(repository repository.Repository) {
+func Init(repository repository.Repository, smsSender sms.SMSSender) {
 	if service != nil {
 		return
 	}
 
+	verificationService := NewVerificationService(repository, smsSender)
 	service = &Service{
-		AuthService:  NewAuthService(repository),
-		StoreService: NewStoreService(repository),
+		AuthService:         NewAuthService(repository, verificationService),
+		StoreService:        NewStoreService(repository),
+		APIKeyService:       NewAPIKeyService(repository),
+		MemberService:       NewMemberService(repository),
+		VerificationService: verificationService,
+		SessionService:      NewSessionService(repository),
+		TwoFactorService:    NewTwoFactorService(repository),
+		AccountService:      NewAccountService(repository),
 	}
 }
 
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"store-management/internal/apperror"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"store-management/internal/repository"
+	"time"
+)
+
+var (
+	ErrSessionNotFound = apperror.New(apperror.KindNotFound, "session not found")
+	ErrSessionRevoked  = apperror.New(apperror.KindUnauthorized, "session revoked")
+)
+
+// lastSeenInterval bounds how often last_seen_at is written for a session.
+const lastSeenInterval = time.Minute
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userId int64, currentFamilyId string) ([]*model.DeviceSession, error)
+	RevokeSession(ctx context.Context, userId int64, id int64) error
+	RevokeOtherSessions(ctx context.Context, userId int64, currentFamilyId string) error
+	CheckSession(ctx context.Context, userId int64, familyId string, ip string) error
+}
+
+type sessionServiceImpl struct {
+	repo struct {
+		session repository.SessionRepository
+	}
+}
+
+// ListSessions returns the sessions of the user that are still usable, the
+// one with currentFamilyId marked as current.
+func (s *sessionServiceImpl) ListSessions(ctx context.Context, userId int64, currentFamilyId string) ([]*model.DeviceSession, error) {
+	devices, err := s.repo.session.FindDeviceSessionsByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		device.Current = device.FamilyID == currentFamilyId
+	}
+	return devices, nil
+}
+
+func (s *sessionServiceImpl) RevokeSession(ctx context.Context, userId int64, id int64) error {
+	device, err := s.repo.session.FindDeviceSessionByID(ctx, userId, id)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if device.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	return s.repo.session.RevokeSessionFamily(ctx, device.FamilyID)
+}
+
+func (s *sessionServiceImpl) RevokeOtherSessions(ctx context.Context, userId int64, currentFamilyId string) error {
+	return s.repo.session.RevokeOtherSessions(ctx, userId, currentFamilyId)
+}
+
+// CheckSession returns ErrSessionRevoked unless the session of an access
+// token is still live, and records that it was seen from ip.
+func (s *sessionServiceImpl) CheckSession(ctx context.Context, userId int64, familyId string, ip string) error {
+	device, err := s.repo.session.FindDeviceSession(ctx, familyId)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if device.UserID != userId || device.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+
+	now := time.Now()
+	if now.Sub(device.LastSeenAt) >= lastSeenInterval || device.IP != ip {
+		return s.repo.session.TouchDeviceSession(ctx, familyId, now, ip)
+	}
+	return nil
+}
+
+func NewSessionService(repo repository.Repository) SessionService {
+	service := &sessionServiceImpl{}
+	service.repo.session = repo.SessionRepository()
+	return service
+}
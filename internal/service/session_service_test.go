@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	mock2 "store-management/mock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SessionServiceSuite struct {
+	suite.Suite
+	mockedSessionRepository *mock2.SessionRepositoryMock
+	service                 SessionService
+}
+
+func (s *SessionServiceSuite) SetupTest() {
+	s.mockedSessionRepository = &mock2.SessionRepositoryMock{}
+	mockRepo := mock2.NewMockedRepository(&mock2.UserRepositoryMock{}, &mock2.StoreRepositoryMock{}, s.mockedSessionRepository)
+	s.service = NewSessionService(mockRepo)
+}
+
+func (s *SessionServiceSuite) TestListSessions_MarksCurrent() {
+	s.mockedSessionRepository.On("FindDeviceSessionsByUserID", int64(1)).Return([]*model.DeviceSession{
+		{ID: 1, FamilyID: "tablet"},
+		{ID: 2, FamilyID: "phone"},
+	}, nil).Once()
+
+	sessions, err := s.service.ListSessions(context.Background(), 1, "phone")
+	s.NoError(err)
+	s.False(sessions[0].Current)
+	s.True(sessions[1].Current)
+}
+
+func (s *SessionServiceSuite) TestRevokeSession_RevokesFamily() {
+	s.mockedSessionRepository.On("FindDeviceSessionByID", int64(1), int64(2)).Return(&model.DeviceSession{ID: 2, UserID: 1, FamilyID: "tablet"}, nil).Once()
+	s.mockedSessionRepository.On("RevokeSessionFamily", "tablet").Return(nil).Once()
+
+	err := s.service.RevokeSession(context.Background(), 1, 2)
+	s.NoError(err)
+
+	s.mockedSessionRepository.AssertExpectations(s.T())
+}
+
+func (s *SessionServiceSuite) TestRevokeSession_NotFound_Error() {
+	s.mockedSessionRepository.On("FindDeviceSessionByID", int64(1), int64(2)).Return(nil, datasource.ErrNoRows).Once()
+
+	err := s.service.RevokeSession(context.Background(), 1, 2)
+	s.ErrorIs(err, ErrSessionNotFound)
+}
+
+func (s *SessionServiceSuite) TestRevokeSession_AlreadyRevoked_Error() {
+	revokedAt := time.Now()
+	s.mockedSessionRepository.On("FindDeviceSessionByID", int64(1), int64(2)).Return(&model.DeviceSession{ID: 2, UserID: 1, FamilyID: "tablet", RevokedAt: &revokedAt}, nil).Once()
+
+	err := s.service.RevokeSession(context.Background(), 1, 2)
+	s.ErrorIs(err, ErrSessionNotFound)
+
+	s.mockedSessionRepository.AssertNotCalled(s.T(), "RevokeSessionFamily", "tablet")
+}
+
+func (s *SessionServiceSuite) TestCheckSession_OtherUser_Revoked() {
+	s.mockedSessionRepository.On("FindDeviceSession", "tablet").Return(&model.DeviceSession{UserID: 2, FamilyID: "tablet", LastSeenAt: time.Now()}, nil).Once()
+
+	err := s.service.CheckSession(context.Background(), 1, "tablet", "127.0.0.1")
+	s.ErrorIs(err, ErrSessionRevoked)
+}
+
+func (s *SessionServiceSuite) TestCheckSession_Unknown_Revoked() {
+	s.mockedSessionRepository.On("FindDeviceSession", "tablet").Return(nil, datasource.ErrNoRows).Once()
+
+	err := s.service.CheckSession(context.Background(), 1, "tablet", "127.0.0.1")
+	s.ErrorIs(err, ErrSessionRevoked)
+}
+
+func TestSessionServiceSuite(t *testing.T) {
+	suite.Run(t, new(SessionServiceSuite))
+}
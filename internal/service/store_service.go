@@ -1,79 +1,236 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"regexp"
+	"store-management/internal/apperror"
+	"store-management/internal/datasource"
+	"store-management/internal/logging"
+	"store-management/internal/metrics"
 	"store-management/internal/model"
+	"store-management/internal/permission"
 	"store-management/internal/repository"
+	"time"
 )
 
 var (
-	ErrStoreNotFound   = errors.New("store not found")
-	ErrProductNotFound = errors.New("product not found")
+	ErrStoreNotFound   = apperror.New(apperror.KindNotFound, "store not found")
+	ErrProductNotFound = apperror.New(apperror.KindNotFound, "product not found")
+	ErrInvalidStore    = apperror.New(apperror.KindValidation, "invalid store")
 )
 
 type StoreService interface {
-	GetStoreByUserID(userId int64) (*model.Store, error)
-	GetProduct(storeId, productId int64) (*model.Product, error)
-	GetProductsWithPagination(storeId, page, size int64) ([]*model.Product, error)
-	CreateProduct(storeId int64, product *model.Product) (int64, error)
-	DeleteProduct(storeId, productId int64) error
-	UpdateProduct(storeId int64, product *model.Product) error
-	SearchProducts(storeId int64, query string) ([]*model.Product, error)
+	ListStores(ctx context.Context, userId int64) ([]*model.Store, error)
+	CreateStore(ctx context.Context, store *model.Store) (*model.Store, error)
+	GetStore(ctx context.Context, storeId int64) (*model.Store, error)
+	UpdateStore(ctx context.Context, store *model.Store) error
+	DeleteStore(ctx context.Context, storeId int64) error
+	ResolveAccess(ctx context.Context, user *model.User, apiKey *model.APIKey, storeId int64) (*StoreAccess, error)
+	GetProduct(ctx context.Context, storeId, productId int64) (*model.Product, error)
+	GetProductsWithPagination(ctx context.Context, storeId, page, size int64) ([]*model.Product, error)
+	CreateProduct(ctx context.Context, storeId int64, product *model.Product) (int64, error)
+	DeleteProduct(ctx context.Context, storeId, productId int64) error
+	UpdateProduct(ctx context.Context, storeId int64, product *model.Product) error
+	SearchProducts(ctx context.Context, storeId int64, query string) ([]*model.Product, error)
 }
 
 type storeServiceImpl struct {
 	repo struct {
 		store   repository.StoreRepository
 		product repository.ProductRepository
+		member  repository.MemberRepository
 	}
 }
 
-func (s *storeServiceImpl) GetStoreByUserID(userId int64) (*model.Store, error) {
-	if store, err := s.repo.store.FindStoreByUserID(userId); err != nil {
-		return nil, ErrStoreNotFound
-	} else {
-		return store, nil
+func (s *storeServiceImpl) ListStores(ctx context.Context, userId int64) ([]*model.Store, error) {
+	return s.repo.store.FindStoresByUserID(ctx, userId)
+}
+
+var businessHourPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+var businessDays = map[string]struct{}{
+	"mon": {}, "tue": {}, "wed": {}, "thu": {}, "fri": {}, "sat": {}, "sun": {},
+}
+
+func validateStore(store *model.Store) error {
+	if store.Name == "" {
+		return ErrInvalidStore
 	}
+	if _, err := time.LoadLocation(store.Timezone); err != nil || store.Timezone == "" {
+		return ErrInvalidStore
+	}
+	for _, hour := range store.BusinessHours {
+		if _, ok := businessDays[hour.Day]; !ok {
+			return ErrInvalidStore
+		}
+		if !businessHourPattern.MatchString(hour.Open) || !businessHourPattern.MatchString(hour.Close) {
+			return ErrInvalidStore
+		}
+	}
+	return nil
 }
 
-func (s *storeServiceImpl) GetProduct(storeId, productId int64) (*model.Product, error) {
-	product, err := s.repo.product.FindProduct(storeId, productId)
+// CreateStore creates a store owned by store.UserID.
+func (s *storeServiceImpl) CreateStore(ctx context.Context, store *model.Store) (*model.Store, error) {
+	if store.Timezone == "" {
+		store.Timezone = model.DefaultTimezone
+	}
+	if err := validateStore(store); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.store.CreateStore(ctx, store)
 	if err != nil {
-		return nil, ErrProductNotFound
+		return nil, err
 	}
-	return product, nil
+	return s.GetStore(ctx, id)
+}
 
+func (s *storeServiceImpl) GetStore(ctx context.Context, storeId int64) (*model.Store, error) {
+	store, err := s.repo.store.FindStore(ctx, storeId)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrStoreNotFound
+		}
+		return nil, err
+	}
+	return store, nil
 }
 
-func (s *storeServiceImpl) CreateProduct(storeId int64, product *model.Product) (int64, error) {
-	return s.repo.product.CreateProduct(storeId, product)
+func (s *storeServiceImpl) UpdateStore(ctx context.Context, store *model.Store) error {
+	if err := validateStore(store); err != nil {
+		return err
+	}
+	if err := s.repo.store.UpdateStore(ctx, store); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrStoreNotFound
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *storeServiceImpl) DeleteProduct(storeId, productId int64) error {
-	if err := s.repo.product.DeleteProduct(storeId, productId); err != nil {
-		return ErrProductNotFound
+func (s *storeServiceImpl) DeleteStore(ctx context.Context, storeId int64) error {
+	if err := s.repo.store.DeleteStore(ctx, storeId); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrStoreNotFound
+		}
+		return err
 	}
 	return nil
 }
 
-func (s *storeServiceImpl) UpdateProduct(userId int64, product *model.Product) error {
-	store, err := s.repo.store.FindStoreByUserID(userId)
+// StoreAccess is what a principal may do in the store a request acts on.
+type StoreAccess struct {
+	Store       *model.Store
+	Role        model.Role
+	Permissions permission.Set
+}
+
+func (a *StoreAccess) Can(p permission.Permission) bool {
+	return a.Permissions.Has(p)
+}
+
+// ResolveAccess resolves the store a request acts on and the permissions the
+// principal has there. An API key may only act on the store it belongs to,
+// with the permissions of its scopes. A user must be a member of the store and
+// gets the permissions of their role. When storeId is 0 the store is implied:
+// the API key's store, or the store the user owns (else the first one they
+// joined). Stores the principal cannot access are reported as not found.
+func (s *storeServiceImpl) ResolveAccess(ctx context.Context, user *model.User, apiKey *model.APIKey, storeId int64) (*StoreAccess, error) {
+	if apiKey != nil {
+		if storeId != 0 && storeId != apiKey.StoreID {
+			return nil, ErrStoreNotFound
+		}
+		return &StoreAccess{
+			Store:       &model.Store{ID: apiKey.StoreID},
+			Permissions: permission.ForScopes(apiKey.Scopes),
+		}, nil
+	}
+
+	var membership *model.StoreMember
+	if storeId != 0 {
+		member, err := s.repo.member.FindMember(ctx, storeId, user.ID)
+		if err != nil {
+			if errors.Is(err, datasource.ErrNoRows) {
+				return nil, ErrStoreNotFound
+			}
+			return nil, err
+		}
+		membership = member
+	} else {
+		memberships, err := s.repo.member.FindMembershipsByUserID(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(memberships) == 0 {
+			return nil, ErrStoreNotFound
+		}
+		membership = memberships[0]
+	}
+
+	logging.SetStoreID(ctx, membership.StoreID)
+	return &StoreAccess{
+		Store:       &model.Store{ID: membership.StoreID},
+		Role:        membership.Role,
+		Permissions: permission.ForRole(membership.Role),
+	}, nil
+}
+
+func (s *storeServiceImpl) GetProduct(ctx context.Context, storeId, productId int64) (*model.Product, error) {
+	product, err := s.repo.product.FindProduct(ctx, storeId, productId)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+func (s *storeServiceImpl) CreateProduct(ctx context.Context, storeId int64, product *model.Product) (int64, error) {
+	productId, err := s.repo.product.CreateProduct(ctx, storeId, product)
 	if err != nil {
-		return ErrProductNotFound
+		return 0, err
 	}
-	return s.repo.product.UpdateProduct(store.ID, product)
+	metrics.ProductsCreated.Inc()
+	return productId, nil
 }
 
-func (s *storeServiceImpl) GetProductsWithPagination(storeId int64, cursor int64, limit int64) ([]*model.Product, error) {
-	return s.repo.product.FindProductsWithPagination(storeId, cursor, limit)
+func (s *storeServiceImpl) DeleteProduct(ctx context.Context, storeId, productId int64) error {
+	if err := s.repo.product.DeleteProduct(ctx, storeId, productId); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *storeServiceImpl) SearchProducts(storeId int64, query string) ([]*model.Product, error) {
-	return s.repo.product.SearchProducts(storeId, query)
+func (s *storeServiceImpl) UpdateProduct(ctx context.Context, storeId int64, product *model.Product) error {
+	if err := s.repo.product.UpdateProduct(ctx, storeId, product); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return nil
 }
+
+func (s *storeServiceImpl) GetProductsWithPagination(ctx context.Context, storeId int64, cursor int64, limit int64) ([]*model.Product, error) {
+	return s.repo.product.FindProductsWithPagination(ctx, storeId, cursor, limit)
+}
+
+func (s *storeServiceImpl) SearchProducts(ctx context.Context, storeId int64, query string) ([]*model.Product, error) {
+	metrics.ProductSearches.Inc()
+	return s.repo.product.SearchProducts(ctx, storeId, query)
+}
+
 func NewStoreService(repo repository.Repository) StoreService {
 	service := &storeServiceImpl{}
 	service.repo.product = repo.ProductRepository()
 	service.repo.store = repo.StoreRepository()
+	service.repo.member = repo.MemberRepository()
 	return service
 }
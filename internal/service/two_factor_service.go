@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"store-management/internal/apperror"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"store-management/internal/repository"
+	"store-management/internal/totp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = apperror.New(apperror.KindConflict, "two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = apperror.New(apperror.KindNotFound, "two-factor authentication not enabled")
+	ErrInvalidTwoFactorCode    = apperror.New(apperror.KindValidation, "invalid two-factor code")
+	ErrInvalidLoginChallenge   = apperror.New(apperror.KindUnauthorized, "invalid login challenge")
+)
+
+const (
+	totpIssuer        = "store-management"
+	recoveryCodeCount = 10
+	loginChallengeTTL = 5 * time.Minute
+)
+
+// secondFactorThrottlePolicy locks the second factor of a user for lockout
+// after five wrong codes in a row, counted across every login challenge and
+// the enrollment and disable endpoints, so that knowing the password or
+// holding a session does not allow unlimited guesses.
+var secondFactorThrottlePolicy = loginThrottlePolicy{
+	freeFailures:    4,
+	lockoutFailures: 5,
+	lockout:         15 * time.Minute,
+	window:          time.Hour,
+}
+
+func secondFactorThrottleKeys(userId int64) []loginThrottleKey {
+	return []loginThrottleKey{{key: fmt.Sprintf("two_factor:%d", userId), policy: secondFactorThrottlePolicy, resetOnSuccess: true}}
+}
+
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorService interface {
+	IsEnabled(ctx context.Context, userId int64) (bool, error)
+	BeginEnrollment(ctx context.Context, user *model.User) (*TOTPEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, user *model.User, code string) ([]string, error)
+	Disable(ctx context.Context, user *model.User, code string) error
+	CreateLoginChallenge(ctx context.Context, userId int64, client *model.SessionClient, returnToken bool) (string, time.Time, error)
+	VerifyLoginChallenge(ctx context.Context, challengeToken string, code string) (*model.LoginChallenge, error)
+}
+
+type twoFactorServiceImpl struct {
+	throttle loginThrottle
+	repo     struct {
+		twoFactor repository.TwoFactorRepository
+	}
+}
+
+func hashTwoFactorSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *twoFactorServiceImpl) IsEnabled(ctx context.Context, userId int64) (bool, error) {
+	enrollment, err := s.repo.twoFactor.FindTOTP(ctx, userId)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return enrollment.Enabled(), nil
+}
+
+// BeginEnrollment creates a new TOTP secret for the user to add to an
+// authenticator app. It does not guard logins until ConfirmEnrollment.
+func (s *twoFactorServiceImpl) BeginEnrollment(ctx context.Context, user *model.User) (*TOTPEnrollment, error) {
+	enabled, err := s.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.twoFactor.SaveTOTP(ctx, &model.TOTP{UserID: user.ID, Secret: secret}); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(totpIssuer, user.PhoneNumber, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication once the user proves
+// the authenticator app works, and returns recovery codes. Only their hashes
+// are kept, so they are shown this one time. Wrong codes are throttled like
+// those of a login challenge.
+func (s *twoFactorServiceImpl) ConfirmEnrollment(ctx context.Context, user *model.User, code string) ([]string, error) {
+	enrollment, err := s.repo.twoFactor.FindTOTP(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	if enrollment.Enabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if err := s.checkCode(ctx, user.ID, func() error { return s.useTOTPCode(ctx, enrollment, code) }); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes := make([]string, recoveryCodeCount)
+	recoveryCodeHashes := make([]string, recoveryCodeCount)
+	for i := range recoveryCodes {
+		if recoveryCodes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		recoveryCodeHashes[i] = hashTwoFactorSecret(recoveryCodes[i])
+	}
+	if err := s.repo.twoFactor.EnableTOTP(ctx, user.ID, recoveryCodeHashes); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// Disable turns two-factor authentication off after checking a current code
+// or a recovery code. Wrong codes are throttled like those of a login
+// challenge, so a stolen session cannot guess its way to turning it off.
+func (s *twoFactorServiceImpl) Disable(ctx context.Context, user *model.User, code string) error {
+	enrollment, err := s.repo.twoFactor.FindTOTP(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if !enrollment.Enabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.checkCode(ctx, user.ID, func() error { return s.useCode(ctx, enrollment, code) }); err != nil {
+		return err
+	}
+	return s.repo.twoFactor.DeleteTOTP(ctx, user.ID)
+}
+
+// CreateLoginChallenge parks a login that passed the password check until a
+// second factor is presented, and returns the token to present it with.
+func (s *twoFactorServiceImpl) CreateLoginChallenge(ctx context.Context, userId int64, client *model.SessionClient, returnToken bool) (string, time.Time, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	challengeToken := base64.RawURLEncoding.EncodeToString(b)
+
+	challenge := &model.LoginChallenge{
+		UserID:      userId,
+		Client:      *client,
+		ReturnToken: returnToken,
+		ExpiresAt:   time.Now().Add(loginChallengeTTL),
+	}
+	if err := s.repo.twoFactor.SaveLoginChallenge(ctx, hashTwoFactorSecret(challengeToken), challenge); err != nil {
+		return "", time.Time{}, err
+	}
+	return challengeToken, challenge.ExpiresAt, nil
+}
+
+// VerifyLoginChallenge completes a login challenge with a TOTP or recovery
+// code. A challenge completes once, and wrong codes are throttled per user
+// across challenges; a locked out user has to log in again.
+func (s *twoFactorServiceImpl) VerifyLoginChallenge(ctx context.Context, challengeToken string, code string) (*model.LoginChallenge, error) {
+	tokenHash := hashTwoFactorSecret(challengeToken)
+	challenge, err := s.repo.twoFactor.FindLoginChallenge(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return nil, ErrInvalidLoginChallenge
+		}
+		return nil, err
+	}
+
+	enrollment, err := s.repo.twoFactor.FindTOTP(ctx, challenge.UserID)
+	if err != nil && !errors.Is(err, datasource.ErrNoRows) {
+		return nil, err
+	}
+	if enrollment == nil || !enrollment.Enabled() {
+		// Disabled since the challenge was created; the password was checked,
+		// so there is nothing left to ask for.
+		s.repo.twoFactor.DeleteLoginChallenge(ctx, tokenHash)
+		return challenge, nil
+	}
+
+	if err := s.checkCode(ctx, challenge.UserID, func() error { return s.useCode(ctx, enrollment, code) }); err != nil {
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			s.repo.twoFactor.DeleteLoginChallenge(ctx, tokenHash)
+		}
+		return nil, err
+	}
+
+	s.repo.twoFactor.DeleteLoginChallenge(ctx, tokenHash)
+	return challenge, nil
+}
+
+// checkCode checks a second factor code of the user with use, counting a
+// wrong code against the per-user throttle. It returns a RateLimitError
+// while the user is locked out and for the wrong code that locks them out.
+func (s *twoFactorServiceImpl) checkCode(ctx context.Context, userId int64, use func() error) error {
+	throttleKeys := secondFactorThrottleKeys(userId)
+	lockedOut, err := s.throttle.attempt(ctx, throttleKeys)
+	if err != nil {
+		return err
+	}
+	if err := use(); err != nil {
+		if !errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.throttle.refund(ctx, throttleKeys)
+			return err
+		}
+		if lockedOut {
+			return &RateLimitError{Err: ErrTooManyLoginAttempts, RetryAfter: secondFactorThrottlePolicy.lockout}
+		}
+		return ErrInvalidTwoFactorCode
+	}
+
+	s.throttle.succeed(ctx, throttleKeys)
+	return nil
+}
+
+// useCode accepts a TOTP code or, failing that, an unused recovery code.
+func (s *twoFactorServiceImpl) useCode(ctx context.Context, enrollment *model.TOTP, code string) error {
+	err := s.useTOTPCode(ctx, enrollment, code)
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		return err
+	}
+
+	err = s.repo.twoFactor.UseRecoveryCode(ctx, enrollment.UserID, hashTwoFactorSecret(normalizeRecoveryCode(code)))
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *twoFactorServiceImpl) useTOTPCode(ctx context.Context, enrollment *model.TOTP, code string) error {
+	step, ok := totp.Validate(enrollment.Secret, strings.TrimSpace(code), time.Now())
+	if !ok || step <= enrollment.LastUsedStep {
+		return ErrInvalidTwoFactorCode
+	}
+	if err := s.repo.twoFactor.UseTOTPStep(ctx, enrollment.UserID, step); err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	return nil
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns a code like "abcde-fghij" with 50 bits of entropy.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode accepts recovery codes typed in any case and with or
+// without the dash.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+func NewTwoFactorService(repo repository.Repository) TwoFactorService {
+	service := &twoFactorServiceImpl{}
+	service.repo.twoFactor = repo.TwoFactorRepository()
+	service.throttle = loginThrottle{repo: repo.LoginAttemptRepository()}
+	return service
+}
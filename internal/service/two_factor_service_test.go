@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"store-management/internal/repository"
+	"store-management/internal/totp"
+	mock2 "store-management/mock"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type TwoFactorServiceSuite struct {
+	suite.Suite
+	mockedTwoFactorRepository *mock2.TwoFactorRepositoryMock
+	loginAttemptRepository    repository.LoginAttemptRepository
+	service                   TwoFactorService
+}
+
+func (s *TwoFactorServiceSuite) SetupTest() {
+	s.mockedTwoFactorRepository = &mock2.TwoFactorRepositoryMock{}
+	mockRepo := mock2.NewMockedRepository(&mock2.UserRepositoryMock{}, &mock2.StoreRepositoryMock{}, &mock2.SessionRepositoryMock{})
+	mockRepo.On("TwoFactorRepository").Return(s.mockedTwoFactorRepository)
+	s.loginAttemptRepository = repository.NewLoginAttemptRepository(datasource.NewInMemoryCache())
+	mockRepo.On("LoginAttemptRepository").Return(s.loginAttemptRepository)
+	s.service = NewTwoFactorService(mockRepo)
+}
+
+func (s *TwoFactorServiceSuite) enabledTOTP(userId int64) *model.TOTP {
+	secret, err := totp.GenerateSecret()
+	s.Require().NoError(err)
+	enabledAt := time.Now()
+	return &model.TOTP{UserID: userId, Secret: secret, EnabledAt: &enabledAt}
+}
+
+func (s *TwoFactorServiceSuite) code(secret string, step int64) string {
+	code, err := totp.Code(secret, step)
+	s.Require().NoError(err)
+	return code
+}
+
+func (s *TwoFactorServiceSuite) TestBeginEnrollment() {
+	user := &model.User{ID: 1, PhoneNumber: "01012345678"}
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(nil, datasource.ErrNoRows).Once()
+	s.mockedTwoFactorRepository.On("SaveTOTP", mock.Anything).Return(nil).Once()
+
+	enrollment, err := s.service.BeginEnrollment(context.Background(), user)
+	s.NoError(err)
+	s.NotEmpty(enrollment.Secret)
+	s.Contains(enrollment.ProvisioningURI, "otpauth://totp/")
+	s.Contains(enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+}
+
+func (s *TwoFactorServiceSuite) TestBeginEnrollment_AlreadyEnabled_Error() {
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(s.enabledTOTP(1), nil).Once()
+
+	_, err := s.service.BeginEnrollment(context.Background(), &model.User{ID: 1})
+	s.ErrorIs(err, ErrTwoFactorAlreadyEnabled)
+}
+
+func (s *TwoFactorServiceSuite) TestConfirmEnrollment_ReturnsRecoveryCodes() {
+	secret, _ := totp.GenerateSecret()
+	step := totp.Step(time.Now())
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(&model.TOTP{UserID: 1, Secret: secret}, nil).Once()
+	s.mockedTwoFactorRepository.On("UseTOTPStep", int64(1), step).Return(nil).Once()
+	s.mockedTwoFactorRepository.On("EnableTOTP", int64(1), mock.Anything).Return(nil).Once()
+
+	recoveryCodes, err := s.service.ConfirmEnrollment(context.Background(), &model.User{ID: 1}, s.code(secret, step))
+	s.NoError(err)
+	s.Len(recoveryCodes, recoveryCodeCount)
+
+	hashes := s.mockedTwoFactorRepository.Calls[2].Arguments.Get(1).([]string)
+	s.Equal(hashTwoFactorSecret(recoveryCodes[0]), hashes[0])
+}
+
+func (s *TwoFactorServiceSuite) TestConfirmEnrollment_WrongCode_Error() {
+	secret, _ := totp.GenerateSecret()
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(&model.TOTP{UserID: 1, Secret: secret}, nil).Once()
+
+	_, err := s.service.ConfirmEnrollment(context.Background(), &model.User{ID: 1}, "abcdef")
+	s.ErrorIs(err, ErrInvalidTwoFactorCode)
+
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "EnableTOTP", mock.Anything, mock.Anything)
+}
+
+func (s *TwoFactorServiceSuite) TestDisable_ReplayedCode_Error() {
+	enrollment := s.enabledTOTP(1)
+	step := totp.Step(time.Now())
+	enrollment.LastUsedStep = step
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(enrollment, nil).Once()
+	s.mockedTwoFactorRepository.On("UseRecoveryCode", int64(1), mock.Anything).Return(datasource.ErrNoRows).Once()
+
+	err := s.service.Disable(context.Background(), &model.User{ID: 1}, s.code(enrollment.Secret, step))
+	s.ErrorIs(err, ErrInvalidTwoFactorCode)
+
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "UseTOTPStep", mock.Anything, mock.Anything)
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "DeleteTOTP", mock.Anything)
+}
+
+func (s *TwoFactorServiceSuite) TestDisable_RecoveryCode() {
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(s.enabledTOTP(1), nil).Once()
+	s.mockedTwoFactorRepository.On("UseRecoveryCode", int64(1), hashTwoFactorSecret("abcde-fghij")).Return(nil).Once()
+	s.mockedTwoFactorRepository.On("DeleteTOTP", int64(1)).Return(nil).Once()
+
+	err := s.service.Disable(context.Background(), &model.User{ID: 1}, " ABCDEFGHIJ ")
+	s.NoError(err)
+
+	s.mockedTwoFactorRepository.AssertExpectations(s.T())
+}
+
+func (s *TwoFactorServiceSuite) TestDisable_LockedOut_RejectsCorrectCode() {
+	enrollment := s.enabledTOTP(1)
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(enrollment, nil)
+	s.mockedTwoFactorRepository.On("UseRecoveryCode", int64(1), mock.Anything).Return(datasource.ErrNoRows)
+
+	for i := 0; i < secondFactorThrottlePolicy.freeFailures; i++ {
+		s.ErrorIs(s.service.Disable(context.Background(), &model.User{ID: 1}, "abcdef"), ErrInvalidTwoFactorCode)
+	}
+	s.ErrorIs(s.service.Disable(context.Background(), &model.User{ID: 1}, "abcdef"), ErrTooManyLoginAttempts)
+
+	err := s.service.Disable(context.Background(), &model.User{ID: 1}, s.code(enrollment.Secret, totp.Step(time.Now())))
+	var rateLimitErr *RateLimitError
+	s.Require().ErrorAs(err, &rateLimitErr)
+	s.ErrorIs(err, ErrTooManyLoginAttempts)
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "UseTOTPStep", mock.Anything, mock.Anything)
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "DeleteTOTP", mock.Anything)
+}
+
+func (s *TwoFactorServiceSuite) TestConfirmEnrollment_LockedOut_RejectsCorrectCode() {
+	secret, _ := totp.GenerateSecret()
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(&model.TOTP{UserID: 1, Secret: secret}, nil)
+
+	for i := 0; i < secondFactorThrottlePolicy.freeFailures; i++ {
+		_, err := s.service.ConfirmEnrollment(context.Background(), &model.User{ID: 1}, "abcdef")
+		s.ErrorIs(err, ErrInvalidTwoFactorCode)
+	}
+	_, err := s.service.ConfirmEnrollment(context.Background(), &model.User{ID: 1}, "abcdef")
+	s.ErrorIs(err, ErrTooManyLoginAttempts)
+
+	_, err = s.service.ConfirmEnrollment(context.Background(), &model.User{ID: 1}, s.code(secret, totp.Step(time.Now())))
+	s.ErrorIs(err, ErrTooManyLoginAttempts)
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "UseTOTPStep", mock.Anything, mock.Anything)
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "EnableTOTP", mock.Anything, mock.Anything)
+}
+
+func (s *TwoFactorServiceSuite) TestDisable_Success_ResetsFailures() {
+	enrollment := s.enabledTOTP(1)
+	step := totp.Step(time.Now())
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(enrollment, nil)
+	s.mockedTwoFactorRepository.On("UseRecoveryCode", int64(1), mock.Anything).Return(datasource.ErrNoRows)
+	s.mockedTwoFactorRepository.On("UseTOTPStep", int64(1), step).Return(nil).Once()
+	s.mockedTwoFactorRepository.On("DeleteTOTP", int64(1)).Return(nil).Once()
+
+	s.ErrorIs(s.service.Disable(context.Background(), &model.User{ID: 1}, "abcdef"), ErrInvalidTwoFactorCode)
+	s.NoError(s.service.Disable(context.Background(), &model.User{ID: 1}, s.code(enrollment.Secret, step)))
+
+	attempts, err := s.loginAttemptRepository.FindLoginAttempts(context.Background(), "two_factor:1")
+	s.Require().NoError(err)
+	s.Zero(attempts.Failures)
+}
+
+func (s *TwoFactorServiceSuite) TestVerifyLoginChallenge() {
+	enrollment := s.enabledTOTP(1)
+	step := totp.Step(time.Now())
+	tokenHash := hashTwoFactorSecret("challenge")
+	s.mockedTwoFactorRepository.On("FindLoginChallenge", tokenHash).Return(&model.LoginChallenge{UserID: 1, ReturnToken: true}, nil).Once()
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(enrollment, nil).Once()
+	s.mockedTwoFactorRepository.On("UseTOTPStep", int64(1), step).Return(nil).Once()
+	s.mockedTwoFactorRepository.On("DeleteLoginChallenge", tokenHash).Once()
+
+	challenge, err := s.service.VerifyLoginChallenge(context.Background(), "challenge", s.code(enrollment.Secret, step))
+	s.NoError(err)
+	s.Equal(int64(1), challenge.UserID)
+	s.True(challenge.ReturnToken)
+
+	s.mockedTwoFactorRepository.AssertExpectations(s.T())
+}
+
+func (s *TwoFactorServiceSuite) TestVerifyLoginChallenge_Unknown_Error() {
+	s.mockedTwoFactorRepository.On("FindLoginChallenge", mock.Anything).Return(nil, datasource.ErrNoRows).Once()
+
+	_, err := s.service.VerifyLoginChallenge(context.Background(), "challenge", "123456")
+	s.ErrorIs(err, ErrInvalidLoginChallenge)
+}
+
+func (s *TwoFactorServiceSuite) TestVerifyLoginChallenge_LockoutLastsAcrossChallenges() {
+	enrollment := s.enabledTOTP(1)
+	s.mockedTwoFactorRepository.On("FindLoginChallenge", mock.Anything).Return(&model.LoginChallenge{UserID: 1}, nil)
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(enrollment, nil)
+	s.mockedTwoFactorRepository.On("UseRecoveryCode", int64(1), mock.Anything).Return(datasource.ErrNoRows)
+	s.mockedTwoFactorRepository.On("DeleteLoginChallenge", mock.Anything)
+
+	for i := 0; i < secondFactorThrottlePolicy.freeFailures; i++ {
+		_, err := s.service.VerifyLoginChallenge(context.Background(), fmt.Sprintf("challenge-%d", i), "abcdef")
+		s.ErrorIs(err, ErrInvalidTwoFactorCode)
+	}
+	_, err := s.service.VerifyLoginChallenge(context.Background(), "challenge-last", "abcdef")
+	s.ErrorIs(err, ErrTooManyLoginAttempts)
+	s.mockedTwoFactorRepository.AssertCalled(s.T(), "DeleteLoginChallenge", hashTwoFactorSecret("challenge-last"))
+
+	_, err = s.service.VerifyLoginChallenge(context.Background(), "challenge-new", s.code(enrollment.Secret, totp.Step(time.Now())))
+	var rateLimitErr *RateLimitError
+	s.Require().ErrorAs(err, &rateLimitErr)
+	s.ErrorIs(err, ErrTooManyLoginAttempts)
+	s.mockedTwoFactorRepository.AssertNotCalled(s.T(), "UseTOTPStep", mock.Anything, mock.Anything)
+	s.mockedTwoFactorRepository.AssertNumberOfCalls(s.T(), "UseRecoveryCode", secondFactorThrottlePolicy.lockoutFailures)
+}
+
+func (s *TwoFactorServiceSuite) TestVerifyLoginChallenge_ConcurrentFailures_Throttled() {
+	s.mockedTwoFactorRepository.On("FindLoginChallenge", mock.Anything).Return(&model.LoginChallenge{UserID: 1}, nil)
+	s.mockedTwoFactorRepository.On("FindTOTP", int64(1)).Return(s.enabledTOTP(1), nil)
+	s.mockedTwoFactorRepository.On("UseRecoveryCode", int64(1), mock.Anything).Return(datasource.ErrNoRows)
+	s.mockedTwoFactorRepository.On("DeleteLoginChallenge", mock.Anything)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = s.service.VerifyLoginChallenge(context.Background(), fmt.Sprintf("challenge-%d", i), "abcdef")
+		}(i)
+	}
+	wg.Wait()
+
+	s.mockedTwoFactorRepository.AssertNumberOfCalls(s.T(), "UseRecoveryCode", secondFactorThrottlePolicy.lockoutFailures)
+	attempts, err := s.loginAttemptRepository.FindLoginAttempts(context.Background(), "two_factor:1")
+	s.Require().NoError(err)
+	s.Equal(secondFactorThrottlePolicy.lockoutFailures, attempts.Failures)
+}
+
+func TestTwoFactorServiceSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorServiceSuite))
+}
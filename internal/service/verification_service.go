@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"store-management/internal/apperror"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"store-management/internal/phone"
+	"store-management/internal/repository"
+	"store-management/internal/sms"
+	"time"
+)
+
+var (
+	ErrInvalidVerificationCode  = apperror.New(apperror.KindValidation, "invalid verification code")
+	ErrTooManyAttempts          = apperror.New(apperror.KindTooManyRequests, "too many attempts")
+	ErrVerificationRateLimited  = apperror.New(apperror.KindTooManyRequests, "verification code requested too often")
+	ErrInvalidVerificationToken = apperror.New(apperror.KindForbidden, "invalid verification token")
+)
+
+const (
+	verificationCodeDigits  = 6
+	verificationCodeTTL     = 5 * time.Minute
+	verificationMaxAttempts = 5
+	verificationResendDelay = time.Minute
+	verificationSendWindow  = time.Hour
+	verificationMaxSends    = 5
+	verificationVerifiedTTL = 10 * time.Minute
+)
+
+// RateLimitError reports that an action is refused until RetryAfter has
+// elapsed.
+type RateLimitError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+type VerificationService interface {
+	SendCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string) error
+	VerifyCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, code string) (string, error)
+	ConsumeVerificationToken(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, verificationToken string) error
+}
+
+type verificationServiceImpl struct {
+	sender sms.SMSSender
+	repo   struct {
+		verification repository.VerificationRepository
+	}
+}
+
+func hashVerificationSecret(purpose model.VerificationPurpose, phoneNumber, secret string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%s", purpose, phoneNumber, secret)))
+	return hex.EncodeToString(hash[:])
+}
+
+func newVerificationCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < verificationCodeDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
+
+// SendCode sends a new one-time code to phoneNumber, replacing any code sent
+// before. Sends are limited to one per verificationResendDelay and
+// verificationMaxSends per verificationSendWindow for each number.
+func (s *verificationServiceImpl) SendCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber string) error {
+	phoneNumber, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	previous, err := s.repo.verification.FindCode(ctx, purpose, phoneNumber)
+	if err != nil && !errors.Is(err, datasource.ErrNoRows) {
+		return err
+	}
+	if previous != nil && now.Sub(previous.SentAt) < verificationResendDelay {
+		return &RateLimitError{Err: ErrVerificationRateLimited, RetryAfter: verificationResendDelay - now.Sub(previous.SentAt)}
+	}
+
+	count, err := s.repo.verification.IncreaseSendCount(ctx, phoneNumber, verificationSendWindow)
+	if err != nil {
+		return err
+	}
+	if count > verificationMaxSends {
+		return &RateLimitError{Err: ErrVerificationRateLimited, RetryAfter: verificationSendWindow}
+	}
+
+	code, err := newVerificationCode()
+	if err != nil {
+		return err
+	}
+	err = s.repo.verification.SaveCode(ctx, &model.VerificationCode{
+		PhoneNumber: phoneNumber,
+		Purpose:     purpose,
+		CodeHash:    hashVerificationSecret(purpose, phoneNumber, code),
+		SentAt:      now,
+		ExpiresAt:   now.Add(verificationCodeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.sender.Send(phoneNumber, fmt.Sprintf("[store-management] 인증번호 [%s]를 입력해주세요.", code))
+}
+
+// VerifyCode checks code and, when it matches, returns a verification token
+// proving ownership of phoneNumber for verificationVerifiedTTL. A code can be
+// tried verificationMaxAttempts times before it is discarded.
+func (s *verificationServiceImpl) VerifyCode(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, code string) (string, error) {
+	phoneNumber, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return "", err
+	}
+
+	// The attempt is counted before the code is compared, so that
+	// concurrent attempts cannot get past the limit.
+	stored, err := s.repo.verification.IncreaseCodeAttempts(ctx, purpose, phoneNumber)
+	if err != nil {
+		if errors.Is(err, datasource.ErrNoRows) {
+			return "", ErrInvalidVerificationCode
+		}
+		return "", err
+	}
+	if stored.Attempts > verificationMaxAttempts {
+		s.repo.verification.DeleteCode(ctx, purpose, phoneNumber)
+		return "", ErrTooManyAttempts
+	}
+
+	codeHash := hashVerificationSecret(purpose, phoneNumber, code)
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(stored.CodeHash)) != 1 {
+		if stored.Attempts >= verificationMaxAttempts {
+			s.repo.verification.DeleteCode(ctx, purpose, phoneNumber)
+			return "", ErrTooManyAttempts
+		}
+		return "", ErrInvalidVerificationCode
+	}
+	s.repo.verification.DeleteCode(ctx, purpose, phoneNumber)
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	verificationToken := base64.RawURLEncoding.EncodeToString(b)
+	err = s.repo.verification.SaveVerifiedToken(ctx, purpose, phoneNumber, hashVerificationSecret(purpose, phoneNumber, verificationToken), verificationVerifiedTTL)
+	if err != nil {
+		return "", err
+	}
+	return verificationToken, nil
+}
+
+// ConsumeVerificationToken checks a token returned by VerifyCode. A token can
+// be used only once.
+func (s *verificationServiceImpl) ConsumeVerificationToken(ctx context.Context, purpose model.VerificationPurpose, phoneNumber, verificationToken string) error {
+	phoneNumber, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.repo.verification.ConsumeVerifiedToken(ctx, purpose, phoneNumber, hashVerificationSecret(purpose, phoneNumber, verificationToken))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+func NewVerificationService(repo repository.Repository, sender sms.SMSSender) VerificationService {
+	service := &verificationServiceImpl{sender: sender}
+	service.repo.verification = repo.VerificationRepository()
+	return service
+}
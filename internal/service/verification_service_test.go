@@ -0,0 +1,178 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"store-management/internal/datasource"
+	"store-management/internal/model"
+	"store-management/internal/repository"
+	"store-management/internal/sms"
+	mock2 "store-management/mock"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type VerificationServiceSuite struct {
+	suite.Suite
+	outbox                 *bytes.Buffer
+	verificationRepository repository.VerificationRepository
+	service                VerificationService
+}
+
+func (s *VerificationServiceSuite) SetupTest() {
+	s.outbox = &bytes.Buffer{}
+	s.verificationRepository = repository.NewVerificationRepository(datasource.NewInMemoryCache())
+	mockRepo := &mock2.MockedRepository{}
+	mockRepo.On("VerificationRepository").Return(s.verificationRepository)
+	s.service = NewVerificationService(mockRepo, sms.NewWriterSender(s.outbox))
+}
+
+var sentCodePattern = regexp.MustCompile(`\[(\d{6})\]`)
+
+func (s *VerificationServiceSuite) sentCode() string {
+	matches := sentCodePattern.FindAllStringSubmatch(s.outbox.String(), -1)
+	s.Require().NotEmpty(matches)
+	return matches[len(matches)-1][1]
+}
+
+func (s *VerificationServiceSuite) TestSendCode_NormalizesPhoneNumber() {
+	s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "010-1234-5678"))
+	s.Contains(s.outbox.String(), "+821012345678")
+
+	code, err := s.verificationRepository.FindCode(context.Background(), model.VerificationPurposeRegister, "+821012345678")
+	s.Require().NoError(err)
+	s.NotContains(code.CodeHash, s.sentCode())
+}
+
+func (s *VerificationServiceSuite) TestSendCode_InvalidPhoneNumber_Error() {
+	err := s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "12")
+	s.Error(err)
+	s.Zero(s.outbox.Len())
+}
+
+func (s *VerificationServiceSuite) TestSendCode_TooSoon_RateLimited() {
+	s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"))
+
+	err := s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678")
+	s.ErrorIs(err, ErrVerificationRateLimited)
+	var rateLimitErr *RateLimitError
+	s.Require().ErrorAs(err, &rateLimitErr)
+	s.Greater(rateLimitErr.RetryAfter, time.Duration(0))
+}
+
+func (s *VerificationServiceSuite) TestSendCode_HourlyLimit_RateLimited() {
+	for i := 0; i < verificationMaxSends; i++ {
+		s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"))
+		// Pretend the previous code was sent long enough ago to resend.
+		code, err := s.verificationRepository.FindCode(context.Background(), model.VerificationPurposeRegister, "+821012345678")
+		s.Require().NoError(err)
+		code.SentAt = code.SentAt.Add(-verificationResendDelay)
+		s.Require().NoError(s.verificationRepository.SaveCode(context.Background(), code))
+	}
+
+	err := s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678")
+	s.ErrorIs(err, ErrVerificationRateLimited)
+}
+
+func (s *VerificationServiceSuite) TestVerifyCode_Success() {
+	s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"))
+
+	verificationToken, err := s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "010-1234-5678", s.sentCode())
+	s.Require().NoError(err)
+	s.NotEmpty(verificationToken)
+
+	s.NoError(s.service.ConsumeVerificationToken(context.Background(), model.VerificationPurposeRegister, "+821012345678", verificationToken))
+	s.ErrorIs(s.service.ConsumeVerificationToken(context.Background(), model.VerificationPurposeRegister, "+821012345678", verificationToken), ErrInvalidVerificationToken)
+}
+
+func (s *VerificationServiceSuite) TestVerifyCode_CodeIsSingleUse() {
+	s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"))
+	code := s.sentCode()
+
+	_, err := s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", code)
+	s.Require().NoError(err)
+
+	_, err = s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", code)
+	s.ErrorIs(err, ErrInvalidVerificationCode)
+}
+
+func (s *VerificationServiceSuite) TestVerifyCode_TooManyAttempts_Error() {
+	s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"))
+	code := s.sentCode()
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+
+	for i := 0; i < verificationMaxAttempts-1; i++ {
+		_, err := s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", wrongCode)
+		s.ErrorIs(err, ErrInvalidVerificationCode)
+	}
+	_, err := s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", wrongCode)
+	s.ErrorIs(err, ErrTooManyAttempts)
+
+	_, err = s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", code)
+	s.ErrorIs(err, ErrInvalidVerificationCode)
+}
+
+func (s *VerificationServiceSuite) TestVerifyCode_ConcurrentAttempts_Limited() {
+	s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"))
+	code := s.sentCode()
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4*verificationMaxAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", wrongCode)
+		}()
+	}
+	wg.Wait()
+
+	_, err := s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", code)
+	s.ErrorIs(err, ErrInvalidVerificationCode)
+}
+
+func (s *VerificationServiceSuite) TestSendCode_ConcurrentSends_Limited() {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		sent int
+	)
+	for i := 0; i < 4*verificationMaxSends; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"); err == nil {
+				mu.Lock()
+				sent++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.Positive(sent)
+	s.LessOrEqual(sent, verificationMaxSends)
+}
+
+func (s *VerificationServiceSuite) TestConsumeVerificationToken_OtherPhoneNumber_Error() {
+	s.Require().NoError(s.service.SendCode(context.Background(), model.VerificationPurposeRegister, "01012345678"))
+	verificationToken, err := s.service.VerifyCode(context.Background(), model.VerificationPurposeRegister, "01012345678", s.sentCode())
+	s.Require().NoError(err)
+
+	err = s.service.ConsumeVerificationToken(context.Background(), model.VerificationPurposeRegister, "01099999999", verificationToken)
+	s.ErrorIs(err, ErrInvalidVerificationToken)
+}
+
+func TestVerificationServiceSuite(t *testing.T) {
+	suite.Run(t, new(VerificationServiceSuite))
+}
@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type SMSSender interface {
+	Send(phoneNumber string, message string) error
+}
+
+// WriterSender writes messages to an io.Writer instead of delivering them.
+// It is meant for local development and tests.
+type WriterSender struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSender(w io.Writer) *WriterSender {
+	return &WriterSender{w: w}
+}
+
+func NewConsoleSender() *WriterSender {
+	return NewWriterSender(os.Stdout)
+}
+
+// NewFileSender appends messages to the file at path, creating it if needed.
+func NewFileSender(path string) (*WriterSender, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSender(f), nil
+}
+
+func (s *WriterSender) Send(phoneNumber string, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.w, "[sms] %s to=%s %s\n", time.Now().Format(time.RFC3339), phoneNumber, message)
+	return err
+}
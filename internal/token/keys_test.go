@@ -0,0 +1,213 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func privateKeyPEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func publicKeyPEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func writeKeyFile(t *testing.T, keys ...map[string]string) string {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func useKeySet(t *testing.T, keys *KeySet) {
+	previous := keySet.Load()
+	SetKeySet(keys)
+	t.Cleanup(func() { keySet.Store(previous) })
+}
+
+func TestLoadKeySet_SignsWithActiveKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for alg, key := range map[string]interface{}{"ES256": ecKey, "EdDSA": edKey} {
+		t.Run(alg, func(t *testing.T) {
+			keys, err := LoadKeySet(writeKeyFile(t, map[string]string{"kid": "k1", "alg": alg, "status": "active", "private_key": privateKeyPEM(t, key)}))
+			require.NoError(t, err)
+			useKeySet(t, keys)
+
+			tokenString, _, err := Issue(1, "session")
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, "k1", parsed.Header["kid"])
+			assert.Equal(t, alg, parsed.Method.Alg())
+
+			claims, err := Parse(tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, "session", claims.SessionID)
+		})
+	}
+}
+
+func TestParse_Rotation(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	oldKeys, err := LoadKeySet(writeKeyFile(t, map[string]string{"kid": "old", "alg": "ES256", "status": "active", "private_key": privateKeyPEM(t, oldKey)}))
+	require.NoError(t, err)
+	useKeySet(t, oldKeys)
+	oldToken, _, err := Issue(1, "")
+	require.NoError(t, err)
+
+	rotated, err := LoadKeySet(writeKeyFile(t,
+		map[string]string{"kid": "new", "alg": "EdDSA", "status": "active", "private_key": privateKeyPEM(t, newKey)},
+		map[string]string{"kid": "old", "alg": "ES256", "status": "verify", "public_key": publicKeyPEM(t, &oldKey.PublicKey)},
+	))
+	require.NoError(t, err)
+	useKeySet(t, rotated)
+	_, err = Parse(oldToken)
+	assert.NoError(t, err)
+
+	retired, err := LoadKeySet(writeKeyFile(t,
+		map[string]string{"kid": "new", "alg": "EdDSA", "status": "active", "private_key": privateKeyPEM(t, newKey)},
+		map[string]string{"kid": "old", "alg": "ES256", "status": "retired", "public_key": publicKeyPEM(t, &oldKey.PublicKey)},
+	))
+	require.NoError(t, err)
+	useKeySet(t, retired)
+	_, err = Parse(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestParse_TokenWithoutKidUsesLegacyKey(t *testing.T) {
+	keys, err := NewHMACKeySet([]byte("test-secret-of-at-least-32-bytes"))
+	require.NoError(t, err)
+	useKeySet(t, keys)
+
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "jti", Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret-of-at-least-32-bytes"))
+	require.NoError(t, err)
+
+	_, err = Parse(tokenString)
+	assert.NoError(t, err)
+}
+
+func TestNewHMACKeySet_ShortSecret(t *testing.T) {
+	_, err := NewHMACKeySet([]byte("nweiocujy4cf2178"))
+	assert.ErrorIs(t, err, ErrInvalidKeySet)
+}
+
+func TestParse_AlgorithmMustMatchKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keys, err := LoadKeySet(writeKeyFile(t, map[string]string{"kid": "k1", "alg": "ES256", "status": "active", "private_key": privateKeyPEM(t, ecKey)}))
+	require.NoError(t, err)
+	useKeySet(t, keys)
+
+	// An HMAC token keyed with the public key must not verify.
+	publicKey, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "jti", Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}})
+	token.Header["kid"] = "k1"
+	tokenString, err := token.SignedString(publicKey)
+	require.NoError(t, err)
+
+	_, err = Parse(tokenString)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestLoadKeySet_Invalid(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	secret := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	tests := map[string][]map[string]string{
+		"no active key": {
+			{"kid": "k1", "alg": "HS256", "status": "verify", "secret": secret},
+		},
+		"two active keys": {
+			{"kid": "k1", "alg": "HS256", "status": "active", "secret": secret},
+			{"kid": "k2", "alg": "HS256", "status": "active", "secret": secret},
+		},
+		"duplicate kid": {
+			{"kid": "k1", "alg": "HS256", "status": "active", "secret": secret},
+			{"kid": "k1", "alg": "HS256", "status": "verify", "secret": secret},
+		},
+		"active key without private key": {
+			{"kid": "k1", "alg": "ES256", "status": "active", "public_key": publicKeyPEM(t, &ecKey.PublicKey)},
+		},
+		"short active secret": {
+			{"kid": "k1", "alg": "HS256", "status": "active", "secret": base64.StdEncoding.EncodeToString([]byte("short"))},
+		},
+		"short verify secret": {
+			{"kid": "k1", "alg": "HS256", "status": "active", "secret": secret},
+			{"kid": "k2", "alg": "HS256", "status": "verify", "secret": base64.StdEncoding.EncodeToString([]byte("short"))},
+		},
+		"empty verify secret": {
+			{"kid": "k1", "alg": "HS256", "status": "active", "secret": secret},
+			{"kid": "k2", "alg": "HS256", "status": "verify", "secret": ""},
+		},
+		"missing verify secret": {
+			{"kid": "k1", "alg": "HS256", "status": "active", "secret": secret},
+			{"kid": "k2", "alg": "HS256", "status": "verify"},
+		},
+		"key of another algorithm": {
+			{"kid": "k1", "alg": "EdDSA", "status": "active", "private_key": privateKeyPEM(t, ecKey)},
+		},
+		"unsupported algorithm": {
+			{"kid": "k1", "alg": "RS256", "status": "active", "secret": secret},
+		},
+	}
+	for name, keys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadKeySet(writeKeyFile(t, keys...))
+			assert.ErrorIs(t, err, ErrInvalidKeySet)
+		})
+	}
+}
+
+func TestJWKS_PublishesOnlyPublicKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys, err := LoadKeySet(writeKeyFile(t,
+		map[string]string{"kid": "ec", "alg": "ES256", "status": "active", "private_key": privateKeyPEM(t, ecKey)},
+		map[string]string{"kid": "ed", "alg": "EdDSA", "status": "verify", "private_key": privateKeyPEM(t, edKey)},
+		map[string]string{"kid": "hmac", "alg": "HS256", "status": "verify", "secret": base64.StdEncoding.EncodeToString([]byte("legacy-secret-of-at-least-32-bytes"))},
+		map[string]string{"kid": "gone", "alg": "ES256", "status": "retired", "public_key": publicKeyPEM(t, &ecKey.PublicKey)},
+	))
+	require.NoError(t, err)
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, JWK{KeyType: "EC", KeyID: "ec", Algorithm: "ES256", Use: "sig", Curve: "P-256",
+		X: encodeCoordinate(ecKey.X.Bytes(), 32), Y: encodeCoordinate(ecKey.Y.Bytes(), 32)}, jwks.Keys[0])
+	assert.Equal(t, JWK{KeyType: "OKP", KeyID: "ed", Algorithm: "EdDSA", Use: "sig", Curve: "Ed25519",
+		X: base64.RawURLEncoding.EncodeToString(edPublic)}, jwks.Keys[1])
+}
@@ -0,0 +1,29 @@
+package token
+
+import (
+	"net/http"
+	"strings"
+)
+
+type Source string
+
+const (
+	SourceNone   Source = ""
+	SourceBearer Source = "bearer"
+	SourceCookie Source = "cookie"
+)
+
+const bearerScheme = "bearer"
+
+// FromRequest returns the access token presented with r and where it was
+// found. An Authorization header using the Bearer scheme always takes
+// precedence over the auth cookie; other schemes are ignored.
+func FromRequest(r *http.Request) (string, Source) {
+	if scheme, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, bearerScheme) {
+		return strings.TrimSpace(credentials), SourceBearer
+	}
+	if cookie, err := r.Cookie(CookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, SourceCookie
+	}
+	return "", SourceNone
+}
@@ -0,0 +1,89 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	CookieName        = "auth_token"
+	RefreshCookieName = "refresh_token"
+	AccessTokenTTL    = time.Hour
+	RefreshTokenTTL   = 14 * 24 * time.Hour
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+}
+
+func (c *Claims) UserID() (int64, error) {
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Issue signs a new access token for the given user and refresh session.
+// Every token carries a unique jti so that it can be revoked individually
+// before it expires.
+func Issue(userID int64, sessionID string) (string, *Claims, error) {
+	jti, err := NewID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.FormatInt(userID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
+		SessionID: sessionID,
+	}
+
+	keys, err := currentKeySet()
+	if err != nil {
+		return "", nil, err
+	}
+	tokenString, err := keys.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, claims, nil
+}
+
+// Parse verifies the signature and expiry of tokenString against any key of
+// the key set that is not retired. Tokens without a jti are rejected because
+// they cannot be revoked.
+func Parse(tokenString string) (*Claims, error) {
+	keys, err := currentKeySet()
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, keys.keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodES256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.ID == "" || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,63 @@
+package mock
+
+import (
+	"context"
+	"store-management/internal/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type APIKeyRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *APIKeyRepositoryMock) CreateAPIKey(ctx context.Context, key *model.APIKey) (int64, error) {
+	args := m.Called(key)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *APIKeyRepositoryMock) FindAPIKey(ctx context.Context, storeId, keyId int64) (*model.APIKey, error) {
+	args := m.Called(storeId, keyId)
+	key := args.Get(0)
+	err := args.Error(1)
+	if key == nil {
+		return nil, err
+	}
+	return key.(*model.APIKey), err
+}
+
+func (m *APIKeyRepositoryMock) FindAPIKeysByStoreID(ctx context.Context, storeId int64) ([]*model.APIKey, error) {
+	args := m.Called(storeId)
+	keys := args.Get(0)
+	err := args.Error(1)
+	if keys == nil {
+		return nil, err
+	}
+	return keys.([]*model.APIKey), err
+}
+
+func (m *APIKeyRepositoryMock) FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	args := m.Called(keyHash)
+	key := args.Get(0)
+	err := args.Error(1)
+	if key == nil {
+		return nil, err
+	}
+	return key.(*model.APIKey), err
+}
+
+func (m *APIKeyRepositoryMock) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *APIKeyRepositoryMock) DeleteAPIKey(ctx context.Context, storeId, keyId int64) error {
+	args := m.Called(storeId, keyId)
+	return args.Error(0)
+}
+
+func (m *APIKeyRepositoryMock) TouchAPIKey(ctx context.Context, keyId int64, usedAt time.Time) error {
+	args := m.Called(keyId, usedAt)
+	return args.Error(0)
+}
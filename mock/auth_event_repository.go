@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+	"store-management/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuthEventRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *AuthEventRepositoryMock) CreateAuthEvent(ctx context.Context, event *model.AuthEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
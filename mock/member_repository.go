@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"context"
+	"store-management/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MemberRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MemberRepositoryMock) FindMember(ctx context.Context, storeId, userId int64) (*model.StoreMember, error) {
+	args := m.Called(storeId, userId)
+	member := args.Get(0)
+	err := args.Error(1)
+	if member == nil {
+		return nil, err
+	}
+	return member.(*model.StoreMember), err
+}
+
+func (m *MemberRepositoryMock) FindMembersByStoreID(ctx context.Context, storeId int64) ([]*model.StoreMember, error) {
+	args := m.Called(storeId)
+	return args.Get(0).([]*model.StoreMember), args.Error(1)
+}
+
+func (m *MemberRepositoryMock) FindMembershipsByUserID(ctx context.Context, userId int64) ([]*model.StoreMember, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]*model.StoreMember), args.Error(1)
+}
+
+func (m *MemberRepositoryMock) DeleteMember(ctx context.Context, storeId, userId int64) error {
+	args := m.Called(storeId, userId)
+	return args.Error(0)
+}
+
+func (m *MemberRepositoryMock) CreateInvitation(ctx context.Context, invitation *model.StoreInvitation) (int64, error) {
+	args := m.Called(invitation)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MemberRepositoryMock) FindInvitation(ctx context.Context, invitationId int64) (*model.StoreInvitation, error) {
+	args := m.Called(invitationId)
+	invitation := args.Get(0)
+	err := args.Error(1)
+	if invitation == nil {
+		return nil, err
+	}
+	return invitation.(*model.StoreInvitation), err
+}
+
+func (m *MemberRepositoryMock) FindPendingInvitationsByStoreID(ctx context.Context, storeId int64) ([]*model.StoreInvitation, error) {
+	args := m.Called(storeId)
+	return args.Get(0).([]*model.StoreInvitation), args.Error(1)
+}
+
+func (m *MemberRepositoryMock) FindPendingInvitationsByPhoneNumber(ctx context.Context, phoneNumber string) ([]*model.StoreInvitation, error) {
+	args := m.Called(phoneNumber)
+	return args.Get(0).([]*model.StoreInvitation), args.Error(1)
+}
+
+func (m *MemberRepositoryMock) AcceptInvitation(ctx context.Context, invitation *model.StoreInvitation, userId int64) error {
+	args := m.Called(invitation, userId)
+	return args.Error(0)
+}
+
+func (m *MemberRepositoryMock) DeleteInvitation(ctx context.Context, storeId, invitationId int64) error {
+	args := m.Called(storeId, invitationId)
+	return args.Error(0)
+}
@@ -0,0 +1,51 @@
+package mock
+
+import (
+	"context"
+	"store-management/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ProductRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ProductRepositoryMock) CreateProduct(ctx context.Context, storeId int64, product *model.Product) (int64, error) {
+	args := m.Called(storeId, product)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) UpdateProduct(ctx context.Context, storeId int64, product *model.Product) error {
+	args := m.Called(storeId, product)
+	return args.Error(0)
+}
+
+func (m *ProductRepositoryMock) DeleteProduct(ctx context.Context, storeId, productId int64) error {
+	args := m.Called(storeId, productId)
+	return args.Error(0)
+}
+
+func (m *ProductRepositoryMock) FindProduct(ctx context.Context, storeId, productId int64) (*model.Product, error) {
+	args := m.Called(storeId, productId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) FindProductsWithPagination(ctx context.Context, storeId int64, cursor int64, limit int64) ([]*model.Product, error) {
+	args := m.Called(storeId, cursor, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Product), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) SearchProducts(ctx context.Context, storeId int64, query string) ([]*model.Product, error) {
+	args := m.Called(storeId, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Product), args.Error(1)
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"store-management/internal/datasource"
 	"store-management/internal/repository"
 
 	"github.com/stretchr/testify/mock"
@@ -21,9 +22,58 @@ func (m *MockedRepository) StoreRepository() repository.StoreRepository {
 	return args.Get(0).(repository.StoreRepository)
 }
 
-func NewMockedRepository(userRepoMock *UserRepositoryMock, storeRepoMock *StoreRepositoryMock) *MockedRepository {
+func (m *MockedRepository) ProductRepository() repository.ProductRepository {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(repository.ProductRepository)
+}
+
+func (m *MockedRepository) SessionRepository() repository.SessionRepository {
+	args := m.Called()
+	return args.Get(0).(repository.SessionRepository)
+}
+
+func (m *MockedRepository) APIKeyRepository() repository.APIKeyRepository {
+	args := m.Called()
+	return args.Get(0).(repository.APIKeyRepository)
+}
+
+func (m *MockedRepository) MemberRepository() repository.MemberRepository {
+	args := m.Called()
+	return args.Get(0).(repository.MemberRepository)
+}
+
+func (m *MockedRepository) VerificationRepository() repository.VerificationRepository {
+	args := m.Called()
+	return args.Get(0).(repository.VerificationRepository)
+}
+
+func (m *MockedRepository) AuthEventRepository() repository.AuthEventRepository {
+	args := m.Called()
+	return args.Get(0).(repository.AuthEventRepository)
+}
+
+func (m *MockedRepository) LoginAttemptRepository() repository.LoginAttemptRepository {
+	args := m.Called()
+	return args.Get(0).(repository.LoginAttemptRepository)
+}
+
+func (m *MockedRepository) TwoFactorRepository() repository.TwoFactorRepository {
+	args := m.Called()
+	return args.Get(0).(repository.TwoFactorRepository)
+}
+
+func (m *MockedRepository) Transaction() datasource.Transaction {
+	args := m.Called()
+	return args.Get(0).(datasource.Transaction)
+}
+
+func NewMockedRepository(userRepoMock *UserRepositoryMock, storeRepoMock *StoreRepositoryMock, sessionRepoMock *SessionRepositoryMock) *MockedRepository {
 	mockRepo := new(MockedRepository)
 	mockRepo.On("UserRepository").Return(userRepoMock)
 	mockRepo.On("StoreRepository").Return(storeRepoMock)
+	mockRepo.On("SessionRepository").Return(sessionRepoMock)
 	return mockRepo
 }
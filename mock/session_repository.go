@@ -0,0 +1,78 @@
+package mock
+
+import (
+	"context"
+	"store-management/internal/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *SessionRepositoryMock) CreateSession(ctx context.Context, session *model.Session, device *model.DeviceSession) error {
+	args := m.Called(session, device)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) FindSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	args := m.Called(tokenHash)
+	session := args.Get(0)
+	err := args.Error(1)
+	if session == nil {
+		return nil, err
+	}
+	return session.(*model.Session), err
+}
+
+func (m *SessionRepositoryMock) RotateSession(ctx context.Context, sessionId int64, next *model.Session) error {
+	args := m.Called(sessionId, next)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) RevokeSessionFamily(ctx context.Context, familyId string) error {
+	args := m.Called(familyId)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) RevokeUserSessions(ctx context.Context, userId int64) error {
+	args := m.Called(userId)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) RevokeOtherSessions(ctx context.Context, userId int64, keepFamilyId string) error {
+	args := m.Called(userId, keepFamilyId)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) FindDeviceSession(ctx context.Context, familyId string) (*model.DeviceSession, error) {
+	args := m.Called(familyId)
+	device := args.Get(0)
+	err := args.Error(1)
+	if device == nil {
+		return nil, err
+	}
+	return device.(*model.DeviceSession), err
+}
+
+func (m *SessionRepositoryMock) FindDeviceSessionByID(ctx context.Context, userId, id int64) (*model.DeviceSession, error) {
+	args := m.Called(userId, id)
+	device := args.Get(0)
+	err := args.Error(1)
+	if device == nil {
+		return nil, err
+	}
+	return device.(*model.DeviceSession), err
+}
+
+func (m *SessionRepositoryMock) FindDeviceSessionsByUserID(ctx context.Context, userId int64) ([]*model.DeviceSession, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]*model.DeviceSession), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) TouchDeviceSession(ctx context.Context, familyId string, seenAt time.Time, ip string) error {
+	args := m.Called(familyId, seenAt, ip)
+	return args.Error(0)
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"store-management/internal/model"
 
 	"github.com/stretchr/testify/mock"
@@ -10,8 +11,13 @@ type StoreRepositoryMock struct {
 	mock.Mock
 }
 
-func (m *StoreRepositoryMock) FindStoreByUserID(userId int64) (*model.Store, error) {
-	args := m.Called(userId)
+func (m *StoreRepositoryMock) CreateStore(ctx context.Context, store *model.Store) (int64, error) {
+	args := m.Called(store)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *StoreRepositoryMock) FindStore(ctx context.Context, storeId int64) (*model.Store, error) {
+	args := m.Called(storeId)
 	store := args.Get(0)
 	err := args.Error(1)
 	if store == nil {
@@ -20,7 +26,17 @@ func (m *StoreRepositoryMock) FindStoreByUserID(userId int64) (*model.Store, err
 	return store.(*model.Store), err
 }
 
-func (m *StoreRepositoryMock) CreateStore(userId int64) error {
+func (m *StoreRepositoryMock) FindStoresByUserID(ctx context.Context, userId int64) ([]*model.Store, error) {
 	args := m.Called(userId)
+	return args.Get(0).([]*model.Store), args.Error(1)
+}
+
+func (m *StoreRepositoryMock) UpdateStore(ctx context.Context, store *model.Store) error {
+	args := m.Called(store)
+	return args.Error(0)
+}
+
+func (m *StoreRepositoryMock) DeleteStore(ctx context.Context, storeId int64) error {
+	args := m.Called(storeId)
 	return args.Error(0)
 }
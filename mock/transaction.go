@@ -0,0 +1,81 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"store-management/internal/datasource"
+	"sync"
+)
+
+type fakeTxContextKey struct{}
+
+// FakeTransaction runs units of work without a database, recording how each
+// one ended so tests can assert that it committed or rolled back. Tx is handed
+// to the TxFunc and may be left nil when the repositories are mocks.
+type FakeTransaction struct {
+	Tx datasource.TxExecer
+
+	mu        sync.Mutex
+	commits   int
+	rollbacks int
+	options   []sql.TxOptions
+}
+
+func (f *FakeTransaction) WithinTx(ctx context.Context, fn datasource.TxFunc, opts ...datasource.TxOption) (err error) {
+	if ctx.Value(fakeTxContextKey{}) != nil {
+		return fn(ctx, f.Tx)
+	}
+
+	var txOptions sql.TxOptions
+	for _, opt := range opts {
+		opt(&txOptions)
+	}
+	f.mu.Lock()
+	f.options = append(f.options, txOptions)
+	f.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			f.end(false)
+			err = fmt.Errorf("%w: %v", datasource.ErrTxPanic, r)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, fakeTxContextKey{}, f), f.Tx); err != nil {
+		f.end(false)
+		return err
+	}
+	f.end(true)
+	return nil
+}
+
+func (f *FakeTransaction) end(committed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if committed {
+		f.commits++
+	} else {
+		f.rollbacks++
+	}
+}
+
+// Commits returns how many units of work committed.
+func (f *FakeTransaction) Commits() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commits
+}
+
+// Rollbacks returns how many units of work rolled back.
+func (f *FakeTransaction) Rollbacks() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rollbacks
+}
+
+// Options returns the options each outermost unit of work was started with.
+func (f *FakeTransaction) Options() []sql.TxOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sql.TxOptions(nil), f.options...)
+}
@@ -0,0 +1,62 @@
+package mock
+
+import (
+	"context"
+	"store-management/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type TwoFactorRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *TwoFactorRepositoryMock) FindTOTP(ctx context.Context, userId int64) (*model.TOTP, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TOTP), args.Error(1)
+}
+
+func (m *TwoFactorRepositoryMock) SaveTOTP(ctx context.Context, totp *model.TOTP) error {
+	args := m.Called(totp)
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepositoryMock) EnableTOTP(ctx context.Context, userId int64, recoveryCodeHashes []string) error {
+	args := m.Called(userId, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepositoryMock) DeleteTOTP(ctx context.Context, userId int64) error {
+	args := m.Called(userId)
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepositoryMock) UseTOTPStep(ctx context.Context, userId int64, step int64) error {
+	args := m.Called(userId, step)
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepositoryMock) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) error {
+	args := m.Called(userId, codeHash)
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepositoryMock) SaveLoginChallenge(ctx context.Context, tokenHash string, challenge *model.LoginChallenge) error {
+	args := m.Called(tokenHash, challenge)
+	return args.Error(0)
+}
+
+func (m *TwoFactorRepositoryMock) FindLoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginChallenge), args.Error(1)
+}
+
+func (m *TwoFactorRepositoryMock) DeleteLoginChallenge(ctx context.Context, tokenHash string) {
+	m.Called(tokenHash)
+}
@@ -1,7 +1,9 @@
 package mock
 
 import (
+	"context"
 	"store-management/internal/model"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,7 +12,7 @@ type UserRepositoryMock struct {
 	mock.Mock
 }
 
-func (m *UserRepositoryMock) FindUser(phoneNumber string) (*model.User, error) {
+func (m *UserRepositoryMock) FindUser(ctx context.Context, phoneNumber string) (*model.User, error) {
 	args := m.Called(phoneNumber)
 	user := args.Get(0)
 	err := args.Error(1)
@@ -20,12 +22,12 @@ func (m *UserRepositoryMock) FindUser(phoneNumber string) (*model.User, error) {
 	return user.(*model.User), err
 }
 
-func (m *UserRepositoryMock) CreateUser(phoneNumber, password string) error {
+func (m *UserRepositoryMock) CreateUser(ctx context.Context, phoneNumber, password string) error {
 	args := m.Called(phoneNumber, password)
 	return args.Error(0)
 }
 
-func (m *UserRepositoryMock) FindUserByID(id int64) (*model.User, error) {
+func (m *UserRepositoryMock) FindUserByID(ctx context.Context, id int64) (*model.User, error) {
 	args := m.Called(id)
 	user := args.Get(0)
 	err := args.Error(1)
@@ -35,7 +37,27 @@ func (m *UserRepositoryMock) FindUserByID(id int64) (*model.User, error) {
 	return user.(*model.User), err
 }
 
-func (m *UserRepositoryMock) BlockAuthToken(token string) error {
-	args := m.Called(token)
+func (m *UserRepositoryMock) UpdatePassword(ctx context.Context, id int64, password string) error {
+	args := m.Called(id, password)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) InvalidateAuthTokens(ctx context.Context, id int64, issuedBefore time.Time) error {
+	args := m.Called(id, issuedBefore)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) BlockAuthToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) IsAuthTokenBlocked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *UserRepositoryMock) DeleteUser(ctx context.Context, user *model.User) error {
+	args := m.Called(user)
 	return args.Error(0)
 }
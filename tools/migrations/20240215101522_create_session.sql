@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE session (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    family_id VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    rotated_at DATETIME NULL DEFAULT NULL,
+    revoked_at DATETIME NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX uniq_token_hash_idx ON session (token_hash);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX family_id_idx ON session (family_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX user_id_idx ON session (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE session;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE store_member (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    store_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX uniq_store_id_user_id_idx ON store_member (store_id, user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX user_id_idx ON store_member (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO store_member (store_id, user_id, role) SELECT id, user_id, 'owner' FROM store;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE store_invitation (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    store_id BIGINT NOT NULL,
+    phone_number VARCHAR(64) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    invited_by BIGINT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    accepted_at DATETIME NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX store_id_idx ON store_invitation (store_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX phone_number_idx ON store_invitation (phone_number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE store_invitation;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE store_member;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE store
+    ADD COLUMN name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN address VARCHAR(512) NOT NULL DEFAULT '',
+    ADD COLUMN phone VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN business_hours TEXT NULL,
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Seoul',
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE store
+    DROP COLUMN name,
+    DROP COLUMN address,
+    DROP COLUMN phone,
+    DROP COLUMN business_hours,
+    DROP COLUMN timezone,
+    DROP COLUMN created_at,
+    DROP COLUMN updated_at;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user ADD COLUMN tokens_valid_after DATETIME NULL AFTER password;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user DROP COLUMN tokens_valid_after;
+-- +goose StatementEnd
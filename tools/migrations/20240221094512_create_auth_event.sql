@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE auth_event (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NULL DEFAULT NULL,
+    phone_number VARCHAR(64) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX user_id_created_at_idx ON auth_event (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX phone_number_created_at_idx ON auth_event (phone_number, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE auth_event;
+-- +goose StatementEnd
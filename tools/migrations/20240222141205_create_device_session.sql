@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE device_session (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    family_id VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    device_label VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    last_seen_at DATETIME NOT NULL,
+    revoked_at DATETIME NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX uniq_family_id_idx ON device_session (family_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX user_id_idx ON device_session (user_id);
+-- +goose StatementEnd
+
+-- Sessions started before devices were tracked get a row without device
+-- details so that their tokens keep working.
+-- +goose StatementBegin
+INSERT INTO device_session (family_id, user_id, last_seen_at, revoked_at, created_at)
+SELECT family_id, user_id, MAX(created_at), MAX(revoked_at), MIN(created_at)
+FROM session
+GROUP BY family_id, user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE device_session;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the goose SQL migrations so the server binary can
+// apply them without goose installed.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS